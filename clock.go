@@ -0,0 +1,49 @@
+package progrock
+
+import (
+	"time"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// Clock is used to determine the current time when no clock has been
+// configured with WithClock.
+var Clock = clockwork.NewRealClock()
+
+// ClockOpt sets the clock used to determine the current time. It may be
+// passed to NewRecorder, NewTape, Pipe, NewUI, or DefaultUI.
+type ClockOpt struct {
+	clock clockwork.Clock
+}
+
+// WithClock sets the clock used to determine the current time, in place of
+// the package-level Clock.
+func WithClock(clock clockwork.Clock) ClockOpt {
+	return ClockOpt{clock}
+}
+
+func (opt ClockOpt) applyRecorder(cfg *recorderConfig) {
+	cfg.clock = opt.clock
+}
+
+func (opt ClockOpt) applyTape(tape *Tape) {
+	tape.clock = opt.clock
+}
+
+func (opt ClockOpt) applyPipe(pipe *unboundedPipe) {
+	pipe.clock = opt.clock
+}
+
+func (opt ClockOpt) applyUI(u *UI) {
+	u.clock = opt.clock
+}
+
+// now returns the current time according to the given clock, falling back to
+// the package-level Clock.
+func now(clock clockwork.Clock) time.Time {
+	if clock == nil {
+		return Clock.Now()
+	}
+
+	return clock.Now()
+}
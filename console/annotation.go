@@ -0,0 +1,187 @@
+package console
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/vito/progrock"
+)
+
+// Conventional label names for pointing a Message at a location in a file.
+const (
+	FileLabel      = "file"
+	LineLabel      = "line"
+	ColumnLabel    = "column"
+	EndLineLabel   = "end_line"
+	EndColumnLabel = "end_column"
+	TitleLabel     = "title"
+)
+
+// AnnotationWriter is a Writer that emits GitHub Actions workflow commands
+// for failed vertexes and for warning and error Messages, so that they're
+// shown as annotations on the run and its pull request checks.
+//
+// Messages with a FileLabel are annotated at that location, along with the
+// LineLabel, ColumnLabel, EndLineLabel, and EndColumnLabel, if set.
+//
+// It is meant to be combined with a Writer that prints the full output, e.g.
+// using progrock.MultiWriter.
+type AnnotationWriter struct {
+	w            io.Writer
+	showInternal bool
+
+	// vertexes that have already been annotated
+	annotated map[string]bool
+
+	l sync.Mutex
+}
+
+// AnnotationOpt is an option for NewAnnotationWriter.
+type AnnotationOpt func(*AnnotationWriter)
+
+// AnnotateInternal annotates failed internal vertexes, too.
+func AnnotateInternal(show bool) AnnotationOpt {
+	return func(w *AnnotationWriter) {
+		w.showInternal = show
+	}
+}
+
+// NewAnnotationWriter returns an AnnotationWriter writing commands to dest,
+// which should be the process's stdout.
+func NewAnnotationWriter(dest io.Writer, opts ...AnnotationOpt) *AnnotationWriter {
+	w := &AnnotationWriter{
+		w:         dest,
+		annotated: map[string]bool{},
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+func (w *AnnotationWriter) WriteStatus(status *progrock.StatusUpdate) error {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	for _, vtx := range status.Vertexes {
+		if vtx.Completed == nil || vtx.Error == nil || vtx.Canceled {
+			continue
+		}
+
+		if vtx.Internal && !w.showInternal {
+			continue
+		}
+
+		if w.annotated[vtx.Id] {
+			continue
+		}
+
+		w.annotated[vtx.Id] = true
+
+		name := strings.Join(strings.Fields(vtx.Name), " ")
+		w.command("error", [][2]string{{"title", name}}, vtx.GetError())
+	}
+
+	for _, msg := range status.Messages {
+		var cmd string
+		switch msg.Level {
+		case progrock.MessageLevel_WARNING:
+			cmd = "warning"
+		case progrock.MessageLevel_ERROR:
+			cmd = "error"
+		default:
+			continue
+		}
+
+		w.command(cmd, messageProperties(msg), msg.Message)
+	}
+
+	return nil
+}
+
+func (w *AnnotationWriter) Close() error {
+	return nil
+}
+
+// command writes a workflow command, e.g. ::error file=foo.go,line=1::oh no.
+func (w *AnnotationWriter) command(name string, props [][2]string, msg string) {
+	var out strings.Builder
+	out.WriteString("::")
+	out.WriteString(name)
+
+	for i, prop := range props {
+		if i == 0 {
+			out.WriteString(" ")
+		} else {
+			out.WriteString(",")
+		}
+
+		out.WriteString(prop[0])
+		out.WriteString("=")
+		out.WriteString(escapeProperty(prop[1]))
+	}
+
+	out.WriteString("::")
+	out.WriteString(escapeData(msg))
+
+	fmt.Fprintln(w.w, out.String())
+}
+
+// messageProperties returns the annotation properties for the message's
+// conventional labels, in the order GitHub documents them.
+func messageProperties(msg *progrock.Message) [][2]string {
+	labels := map[string]string{}
+	for _, l := range msg.Labels {
+		labels[l.Name] = l.Value
+	}
+
+	var props [][2]string
+	if file, found := labels[FileLabel]; found {
+		props = append(props, [2]string{"file", file})
+
+		for _, prop := range [][2]string{
+			{"line", LineLabel},
+			{"endLine", EndLineLabel},
+			{"col", ColumnLabel},
+			{"endColumn", EndColumnLabel},
+		} {
+			if val, found := labels[prop[1]]; found {
+				props = append(props, [2]string{prop[0], val})
+			}
+		}
+	}
+
+	if title, found := labels[TitleLabel]; found {
+		props = append(props, [2]string{"title", title})
+	} else if msg.Code != nil {
+		props = append(props, [2]string{"title", msg.GetCode()})
+	}
+
+	return props
+}
+
+var dataEscaper = strings.NewReplacer(
+	"%", "%25",
+	"\r", "%0D",
+	"\n", "%0A",
+)
+
+var propertyEscaper = strings.NewReplacer(
+	"%", "%25",
+	"\r", "%0D",
+	"\n", "%0A",
+	":", "%3A",
+	",", "%2C",
+)
+
+func escapeData(s string) string {
+	return dataEscaper.Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return propertyEscaper.Replace(s)
+}
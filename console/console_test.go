@@ -2,16 +2,38 @@ package console_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jonboulle/clockwork"
+	"github.com/opencontainers/go-digest"
 	"github.com/sebdah/goldie/v2"
+	"github.com/stretchr/testify/require"
 	"github.com/vito/progrock"
 	"github.com/vito/progrock/console"
+	"github.com/vito/progrock/ui"
 )
 
+func TestMain(m *testing.M) {
+	// don't pick up the CI dialect when running in CI
+	for _, env := range []string{"GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE"} {
+		os.Unsetenv(env)
+	}
+
+	// render with the DarkTheme's ANSI colors regardless of the terminal
+	for _, env := range []string{"NO_COLOR", "PROGROCK_THEME", "COLORTERM", "TERM"} {
+		os.Unsetenv(env)
+	}
+
+	os.Exit(m.Run())
+}
+
 func TestEmpty(t *testing.T) {
 	buf := bytes.NewBuffer(nil)
 	writer := console.NewWriter(buf)
@@ -191,6 +213,642 @@ func TestSingleRunningTasksProgress(t *testing.T) {
 	testGolden(t, buf)
 }
 
+func TestThemeLight(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.WithTheme(ui.LightTheme))
+
+	rec := progrock.NewRecorder(writer)
+
+	vtx := rec.WithGroup("group").Vertex("hey", "sup")
+	fmt.Fprintln(vtx.Stdout(), "hi stdout")
+	vtx.Done(errors.New("oh no!"))
+
+	testGolden(t, buf)
+}
+
+func TestThemeNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf)
+
+	rec := progrock.NewRecorder(writer)
+
+	vtx := rec.WithGroup("group").Vertex("hey", "sup")
+	fmt.Fprintln(vtx.Stdout(), "hi stdout")
+	vtx.Done(errors.New("oh no!"))
+
+	testGolden(t, buf)
+}
+
+func TestDialectGitHubActions(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf,
+		console.WithClock(clockwork.NewFakeClockAt(time.Unix(1700000000, 0))),
+		console.WithDialect(console.GitHubActions),
+		console.ShowSummary(false))
+
+	rec := progrock.NewRecorder(writer)
+
+	ok := rec.WithGroup("group").WithGroup("subgroup").Vertex("ok", "passes")
+	fmt.Fprintln(ok.Stdout(), "hi stdout")
+	ok.Done(nil)
+
+	failed := rec.Vertex("failed", "fails")
+	fmt.Fprintln(failed.Stdout(), "about to fail")
+	fmt.Fprint(failed.Stderr(), "oh no")
+	failed.Done(errors.New("exit status 1"))
+
+	rec.Vertex("running", "still running")
+
+	rec.Close()
+
+	testGolden(t, buf)
+}
+
+func TestDialectGitHubActionsEscapesTitle(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf,
+		console.WithClock(clockwork.NewFakeClockAt(time.Unix(1700000000, 0))),
+		console.WithDialect(console.GitHubActions),
+		console.ShowSummary(false))
+
+	rec := progrock.NewRecorder(writer)
+
+	vtx := rec.Vertex("multiline", "echo 100%\n::endgroup::\n  done")
+	fmt.Fprintln(vtx.Stdout(), "hi stdout")
+	vtx.Done(nil)
+
+	rec.Close()
+
+	require.Contains(t, buf.String(), "::group::echo 100%25 ::endgroup:: done\n")
+	require.NotContains(t, buf.String(), "\n::endgroup::\n  done")
+}
+
+func TestDialectGitLab(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf,
+		console.WithClock(clockwork.NewFakeClockAt(time.Unix(1700000000, 0))),
+		console.WithDialect(console.GitLab),
+		console.ShowSummary(false))
+
+	rec := progrock.NewRecorder(writer)
+
+	ok := rec.WithGroup("group").WithGroup("subgroup").Vertex("ok", "passes")
+	fmt.Fprintln(ok.Stdout(), "hi stdout")
+	ok.Done(nil)
+
+	failed := rec.Vertex("failed", "fails")
+	fmt.Fprintln(failed.Stdout(), "about to fail")
+	fmt.Fprint(failed.Stderr(), "oh no")
+	failed.Done(errors.New("exit status 1"))
+
+	rec.Vertex("running", "still running")
+
+	rec.Close()
+
+	testGolden(t, buf)
+}
+
+func TestDialectBuildkite(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf,
+		console.WithClock(clockwork.NewFakeClockAt(time.Unix(1700000000, 0))),
+		console.WithDialect(console.Buildkite),
+		console.ShowSummary(false))
+
+	rec := progrock.NewRecorder(writer)
+
+	ok := rec.WithGroup("group").WithGroup("subgroup").Vertex("ok", "passes")
+	fmt.Fprintln(ok.Stdout(), "hi stdout")
+	ok.Done(nil)
+
+	failed := rec.Vertex("failed", "fails")
+	fmt.Fprintln(failed.Stdout(), "about to fail")
+	fmt.Fprint(failed.Stderr(), "oh no")
+	failed.Done(errors.New("exit status 1"))
+
+	rec.Vertex("running", "still running")
+
+	rec.Close()
+
+	testGolden(t, buf)
+}
+
+func TestDetectDialect(t *testing.T) {
+	require.Nil(t, console.DetectDialect())
+
+	t.Run("GitHub Actions", func(t *testing.T) {
+		t.Setenv("GITHUB_ACTIONS", "true")
+		require.Equal(t, console.GitHubActions, console.DetectDialect())
+	})
+
+	t.Run("GitLab", func(t *testing.T) {
+		t.Setenv("GITLAB_CI", "true")
+		require.Equal(t, console.GitLab, console.DetectDialect())
+	})
+
+	t.Run("Buildkite", func(t *testing.T) {
+		t.Setenv("BUILDKITE", "true")
+		require.Equal(t, console.Buildkite, console.DetectDialect())
+	})
+}
+
+func TestAnnotations(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	rec := progrock.NewRecorder(console.NewAnnotationWriter(buf))
+
+	rec.Vertex("ok", "passes").Done(nil)
+
+	failed := rec.Vertex("failed", "fails\nwith a long name")
+	fmt.Fprintln(failed.Stdout(), "about to fail")
+	failed.Done(errors.New("exit status 1\n100% broken"))
+	failed.Done(errors.New("exit status 1\n100% broken"))
+
+	rec.Vertex("internal", "internal", progrock.Internal()).Done(errors.New("nope"))
+
+	rec.Vertex("canceled", "canceled").Done(context.Canceled)
+
+	rec.Debug("not annotated")
+	rec.Warn("something looks off")
+	rec.Error("syntax error",
+		progrock.WithMessageCode("E123"),
+		progrock.WithMessageLabels(
+			&progrock.Label{Name: console.FileLabel, Value: "foo, bar.go"},
+			&progrock.Label{Name: console.LineLabel, Value: "12"},
+			&progrock.Label{Name: console.ColumnLabel, Value: "3"},
+		))
+	rec.Warn("deprecated",
+		progrock.WithMessageLabels(
+			&progrock.Label{Name: console.TitleLabel, Value: "Deprecation: old API"},
+		))
+
+	testGolden(t, buf)
+}
+
+func TestMessages(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.ShowSummary(false))
+
+	rec := progrock.NewRecorder(writer)
+
+	vtx := rec.Vertex("hey", "sup")
+	fmt.Fprint(vtx.Stdout(), "partial ")
+
+	rec.Debug("debugging")
+	rec.Warn("something looks off", progrock.WithMessageCode("W001"))
+	rec.Error("syntax error",
+		progrock.WithMessageLabels(
+			&progrock.Label{Name: "file", Value: "foo.go"},
+			&progrock.Label{Name: "line", Value: "12"},
+		))
+
+	fmt.Fprintln(vtx.Stdout(), "line")
+	vtx.Done(nil)
+
+	rec.Close()
+
+	testGolden(t, buf)
+}
+
+func TestMessagesDebug(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.ShowSummary(false), console.WithMessageLevel(progrock.MessageLevel_DEBUG))
+
+	rec := progrock.NewRecorder(writer)
+
+	vtx := rec.Vertex("hey", "sup")
+	fmt.Fprint(vtx.Stdout(), "partial ")
+
+	rec.Debug("debugging")
+	rec.Warn("something looks off", progrock.WithMessageCode("W001"))
+	rec.Error("syntax error",
+		progrock.WithMessageLabels(
+			&progrock.Label{Name: "file", Value: "foo.go"},
+			&progrock.Label{Name: "line", Value: "12"},
+		))
+
+	fmt.Fprintln(vtx.Stdout(), "line")
+	vtx.Done(nil)
+
+	rec.Close()
+
+	testGolden(t, buf)
+}
+
+func TestMessagesError(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.ShowSummary(false), console.WithMessageLevel(progrock.MessageLevel_ERROR))
+
+	rec := progrock.NewRecorder(writer)
+
+	vtx := rec.Vertex("hey", "sup")
+	fmt.Fprint(vtx.Stdout(), "partial ")
+
+	rec.Debug("debugging")
+	rec.Warn("something looks off", progrock.WithMessageCode("W001"))
+	rec.Error("syntax error",
+		progrock.WithMessageLabels(
+			&progrock.Label{Name: "file", Value: "foo.go"},
+			&progrock.Label{Name: "line", Value: "12"},
+		))
+
+	fmt.Fprintln(vtx.Stdout(), "line")
+	vtx.Done(nil)
+
+	rec.Close()
+
+	testGolden(t, buf)
+}
+
+func TestRecap(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.WithClock(clock), console.ShowSummary(false))
+
+	rec := progrock.NewRecorder(writer, progrock.WithClock(clock))
+
+	ok := rec.Vertex("ok", "passes")
+	fmt.Fprintln(ok.Stdout(), "all good")
+	ok.Done(nil)
+
+	failed := rec.WithGroup("group").WithGroup("subgroup").Vertex("failed", "fails")
+	for i := 1; i <= 15; i++ {
+		fmt.Fprintf(failed.Stdout(), "line %d\n", i)
+	}
+	clock.Advance(time.Minute)
+	failed.Done(errors.New("exit status 1"))
+
+	canceled := rec.Vertex("canceled", "interrupted")
+	clock.Advance(time.Second)
+	canceled.Done(context.Canceled)
+
+	internal := rec.Vertex("internal", "internal failure", progrock.Internal())
+	internal.Done(errors.New("nope"))
+
+	another := rec.Vertex("another", "also fails")
+	fmt.Fprint(another.Stderr(), "no trailing newline")
+	another.Done(errors.New("exit status 2"))
+
+	rec.Close()
+
+	testGolden(t, buf)
+}
+
+func TestRecapLines(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.WithClock(clock), console.ShowSummary(false), console.WithRecapLines(2))
+
+	rec := progrock.NewRecorder(writer, progrock.WithClock(clock))
+
+	failed := rec.Vertex("failed", "fails")
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(failed.Stdout(), "line %d\n", i)
+	}
+	failed.Done(errors.New("exit status 1"))
+
+	rec.Close()
+
+	testGolden(t, buf)
+}
+
+func TestRecapNoLines(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.WithClock(clock), console.ShowSummary(false), console.WithRecapLines(0))
+
+	rec := progrock.NewRecorder(writer, progrock.WithClock(clock))
+
+	failed := rec.Vertex("failed", "fails")
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(failed.Stdout(), "line %d\n", i)
+	}
+	failed.Done(errors.New("exit status 1"))
+
+	rec.Close()
+
+	testGolden(t, buf)
+}
+
+func TestTerminal(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf)
+
+	rec := progrock.NewRecorder(writer)
+
+	vtx := rec.Vertex("hey", "sup")
+
+	// progress redrawn with carriage returns
+	for i := 0; i <= 100; i += 25 {
+		fmt.Fprintf(vtx.Stdout(), "\rdownloading... %3d%%", i)
+	}
+	fmt.Fprintln(vtx.Stdout())
+
+	// colors
+	fmt.Fprintln(vtx.Stdout(), "\x1b[32mgreen\x1b[0m and \x1b[1mbold\x1b[0m")
+
+	// erasing and redrawing the line
+	fmt.Fprint(vtx.Stdout(), "please wait")
+	fmt.Fprint(vtx.Stdout(), "\r\x1b[Kdone waiting\n")
+
+	// moving the cursor back to redraw part of the line
+	fmt.Fprint(vtx.Stdout(), "installing: 10%")
+	fmt.Fprint(vtx.Stdout(), "\x1b[3D99%")
+	fmt.Fprint(vtx.Stdout(), "\x1b[3Dok \n")
+
+	// no trailing linebreak
+	fmt.Fprint(vtx.Stdout(), "bye")
+
+	vtx.Done(nil)
+
+	testGolden(t, buf)
+}
+
+func TestTerminalStripANSI(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.StripANSI(true))
+
+	rec := progrock.NewRecorder(writer)
+
+	vtx := rec.Vertex("hey", "sup")
+	fmt.Fprintln(vtx.Stdout(), "\x1b[32mgreen\x1b[0m and \x1b[1mbold\x1b[0m")
+	fmt.Fprint(vtx.Stdout(), "please wait")
+	fmt.Fprint(vtx.Stdout(), "\r\x1b[Kdone waiting\n")
+	vtx.Done(nil)
+
+	testGolden(t, buf)
+}
+
+func TestTerminalLogsAfterDone(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.ShowSummary(false))
+
+	rec := progrock.NewRecorder(writer)
+
+	vtx := rec.Vertex("hey", "sup")
+	fmt.Fprintln(vtx.Stdout(), "hi stdout")
+	vtx.Done(nil)
+
+	// a final line without a linebreak, written after the vertex completed
+	fmt.Fprint(vtx.Stdout(), "bye")
+
+	require.NoError(t, rec.Close())
+
+	testGolden(t, buf)
+}
+
+func TestPrefixesTimestamps(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC))
+
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.WithClock(clock), console.ShowTimestamps(true))
+
+	rec := progrock.NewRecorder(writer, progrock.WithClock(clock))
+
+	vtx := rec.Vertex("hey", "sup")
+	fmt.Fprintln(vtx.Stdout(), "hi stdout")
+	clock.Advance(1500 * time.Millisecond)
+	fmt.Fprintln(vtx.Stderr(), "hi \"stderr\"")
+	vtx.Done(nil)
+
+	testGolden(t, buf)
+}
+
+func TestPrefixesVertexIDs(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC))
+
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.WithClock(clock), console.ShowVertexIDs(true))
+
+	rec := progrock.NewRecorder(writer, progrock.WithClock(clock))
+
+	vtx := rec.Vertex(digest.FromString("hey"), "sup")
+	fmt.Fprintln(vtx.Stdout(), "hi stdout")
+	vtx.Done(nil)
+
+	named := rec.Vertex("named", "named vertex")
+	fmt.Fprintln(named.Stdout(), "hello")
+	named.Done(nil)
+
+	testGolden(t, buf)
+}
+
+func TestPrefixesStreams(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC))
+
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.WithClock(clock), console.ShowStreams(true))
+
+	rec := progrock.NewRecorder(writer, progrock.WithClock(clock))
+
+	vtx := rec.Vertex("hey", "sup")
+	fmt.Fprintln(vtx.Stdout(), "hi stdout")
+	clock.Advance(1500 * time.Millisecond)
+	fmt.Fprintln(vtx.Stderr(), "hi \"stderr\"")
+	vtx.Done(nil)
+
+	testGolden(t, buf)
+}
+
+func TestPrefixesAll(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC))
+
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.WithClock(clock), console.ShowTimestamps(true), console.ShowVertexIDs(true), console.ShowStreams(true))
+
+	rec := progrock.NewRecorder(writer, progrock.WithClock(clock))
+
+	vtx := rec.Vertex(digest.FromString("hey"), "sup")
+	fmt.Fprintln(vtx.Stdout(), "hi stdout")
+	clock.Advance(1500 * time.Millisecond)
+	fmt.Fprintln(vtx.Stderr(), "hi \"stderr\"")
+	vtx.Done(nil)
+
+	named := rec.Vertex("named", "named vertex")
+	fmt.Fprintln(named.Stdout(), "hello")
+	named.Done(nil)
+
+	testGolden(t, buf)
+}
+
+func TestLogfmt(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC))
+
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.WithClock(clock), console.Logfmt(true))
+
+	rec := progrock.NewRecorder(writer, progrock.WithClock(clock))
+
+	vtx := rec.Vertex("hey", "sup")
+	fmt.Fprintln(vtx.Stdout(), "hi stdout")
+	clock.Advance(1500 * time.Millisecond)
+	fmt.Fprintln(vtx.Stderr(), "hi \"stderr\"")
+	vtx.Done(nil)
+
+	named := rec.Vertex("named", "named vertex")
+	fmt.Fprintln(named.Stdout(), "hello")
+	named.Done(nil)
+
+	testGolden(t, buf)
+}
+
+func TestLogfmtVertexIDs(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC))
+
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.WithClock(clock), console.Logfmt(true), console.ShowVertexIDs(true))
+
+	rec := progrock.NewRecorder(writer, progrock.WithClock(clock))
+
+	vtx := rec.Vertex(digest.FromString("hey"), "sup")
+	fmt.Fprintln(vtx.Stdout(), "hi stdout")
+	vtx.Done(nil)
+
+	testGolden(t, buf)
+}
+
+func TestPrefixesVertexIDsCustomUI(t *testing.T) {
+	components := console.DefaultUI
+	components.TextVertexRunning = "[%s] %s"
+	components.TextVertexDone = "[%s] %s done"
+	components.TextLogFormat = "[%s] %s %s"
+
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.WithUI(components), console.ShowVertexIDs(true))
+
+	rec := progrock.NewRecorder(writer)
+
+	vtx := rec.Vertex(digest.FromString("hey"), "sup")
+	fmt.Fprintln(vtx.Stdout(), "hi stdout")
+	vtx.Done(nil)
+
+	require.NotContains(t, buf.String(), "%!")
+	require.Contains(t, buf.String(), "[fa690b82061e] sup done")
+}
+
+func TestSummary(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+
+	buf := bytes.NewBuffer(nil)
+	rec := progrock.NewRecorder(
+		console.NewWriter(buf, console.WithClock(clock)),
+		progrock.WithClock(clock))
+
+	build := rec.WithGroup("build")
+
+	compile := build.Vertex("compile", "go build")
+	clock.Advance(30 * time.Second)
+	compile.Done(nil)
+
+	cached := build.Vertex("download", "go mod download")
+	cached.Cached()
+	cached.Done(nil)
+
+	test := rec.WithGroup("test").WithGroup("unit").Vertex("test", "go test")
+	clock.Advance(90 * time.Second)
+	test.Done(errors.New("exit status 1"))
+
+	lint := rec.Vertex("lint", "golangci-lint run")
+	clock.Advance(5 * time.Second)
+	lint.Done(context.Canceled)
+
+	rec.Vertex("internal", "internal", progrock.Internal()).Done(nil)
+
+	rec.Vertex("deploy", "deploy")
+	clock.Advance(2 * time.Second)
+
+	rec.Close()
+
+	testGolden(t, buf)
+}
+
+func TestSequential(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+
+	buf := bytes.NewBuffer(nil)
+	writer := console.NewWriter(buf, console.WithClock(clock), console.ShowSummary(false), console.Sequential(true))
+
+	rec := progrock.NewRecorder(writer)
+
+	vtx1 := rec.WithGroup("group").Vertex("vtx1", "vtx1")
+	vtx2 := rec.Vertex("vtx2", "vtx2")
+	vtx3 := rec.Vertex("vtx3", "vtx3")
+
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(vtx1.Stdout(), "1.%d hi\n", i)
+		fmt.Fprintf(vtx2.Stdout(), "2.%d hi\n", i)
+		fmt.Fprintf(vtx3.Stdout(), "3.%d hi\n", i)
+		clock.Advance(console.AntiFlicker)
+	}
+
+	vtx2.Done(nil)
+	vtx1.Done(errors.New("oh no!"))
+
+	rec.Close()
+
+	testGolden(t, buf)
+}
+
+func TestHeartbeat(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+
+	buf := new(syncBuffer)
+	writer := console.NewWriter(buf,
+		console.WithClock(clock),
+		console.ShowSummary(false),
+		console.Sequential(true),
+		console.WithHeartbeat(time.Minute))
+
+	rec := progrock.NewRecorder(writer, progrock.WithClock(clock))
+
+	slow := rec.Vertex("slow", "slow")
+	fmt.Fprintln(slow.Stdout(), "working")
+
+	clock.Advance(30 * time.Second)
+	fast := rec.Vertex("fast", "fast")
+	fmt.Fprintln(fast.Stdout(), "working")
+
+	clock.Advance(30 * time.Second)
+	require.Eventually(t, func() bool {
+		return strings.Count(buf.String(), "still running") == 1
+	}, time.Second, time.Millisecond)
+
+	fast.Done(nil)
+
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool {
+		return strings.Count(buf.String(), "still running") == 2
+	}, time.Second, time.Millisecond)
+
+	slow.Done(nil)
+	rec.Close()
+
+	g := goldie.New(t)
+	g.Assert(t, t.Name(), []byte(buf.String()))
+}
+
+// syncBuffer is a buffer that can be written to and read from concurrently.
+type syncBuffer struct {
+	buf bytes.Buffer
+	l   sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.l.Lock()
+	defer b.l.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.l.Lock()
+	defer b.l.Unlock()
+	return b.buf.String()
+}
+
 func testGolden(t *testing.T, buf *bytes.Buffer) {
 	g := goldie.New(t)
 	g.Assert(t, t.Name(), buf.Bytes())
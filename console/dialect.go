@@ -0,0 +1,87 @@
+package console
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Dialect wraps each vertex's output in a CI provider's collapsible section
+// syntax.
+type Dialect interface {
+	// StartSection begins a collapsed section with the given ID and title.
+	StartSection(w io.Writer, now time.Time, id, title string)
+
+	// EndSection ends the section. If the vertex failed, the section should be
+	// left expanded if the dialect supports it, in which case it returns true.
+	// Otherwise the vertex's last logs are printed again after the section.
+	EndSection(w io.Writer, now time.Time, id string, failed bool) bool
+}
+
+// GitHubActions folds output with ::group:: and ::endgroup:: commands.
+var GitHubActions Dialect = gitHubActions{}
+
+// GitLab folds output with section_start and section_end markers.
+var GitLab Dialect = gitLab{}
+
+// Buildkite folds output with --- headers.
+var Buildkite Dialect = buildkite{}
+
+// DetectDialect returns the Dialect for the CI provider the process is
+// running in, as indicated by $GITHUB_ACTIONS, $GITLAB_CI, or $BUILDKITE, or
+// nil if there is none.
+func DetectDialect() Dialect {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return GitHubActions
+	case os.Getenv("GITLAB_CI") == "true":
+		return GitLab
+	case os.Getenv("BUILDKITE") == "true":
+		return Buildkite
+	default:
+		return nil
+	}
+}
+
+type gitHubActions struct{}
+
+func (gitHubActions) StartSection(w io.Writer, now time.Time, id, title string) {
+	// a newline would end the command early and let the rest of the title be
+	// parsed as another command
+	fmt.Fprintf(w, "::group::%s\n", escapeData(strings.Join(strings.Fields(title), " ")))
+}
+
+func (gitHubActions) EndSection(w io.Writer, now time.Time, id string, failed bool) bool {
+	fmt.Fprintln(w, "::endgroup::")
+	return false
+}
+
+type gitLab struct{}
+
+func (gitLab) StartSection(w io.Writer, now time.Time, id, title string) {
+	fmt.Fprintf(w, "\x1b[0Ksection_start:%d:%s[collapsed=true]\r\x1b[0K%s\n", now.Unix(), id, title)
+}
+
+func (gitLab) EndSection(w io.Writer, now time.Time, id string, failed bool) bool {
+	fmt.Fprintf(w, "\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", now.Unix(), id)
+	return false
+}
+
+type buildkite struct{}
+
+func (buildkite) StartSection(w io.Writer, now time.Time, id, title string) {
+	fmt.Fprintf(w, "--- %s\n", title)
+}
+
+func (buildkite) EndSection(w io.Writer, now time.Time, id string, failed bool) bool {
+	// sections end at the next header, but a failed one can be expanded
+	// after the fact
+	if failed {
+		fmt.Fprintln(w, "^^^ +++")
+		return true
+	}
+
+	return false
+}
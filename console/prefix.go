@@ -0,0 +1,127 @@
+package console
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/vito/progrock"
+)
+
+// TimestampFormat is the format of the timestamps shown by ShowTimestamps
+// and in Logfmt mode. It is RFC 3339 with milliseconds.
+const TimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// shortDigestLength is the number of hex characters shown for vertex IDs that
+// are digests.
+const shortDigestLength = 12
+
+// logFormat configures how log lines are formatted.
+type logFormat struct {
+	stripANSI  bool
+	timestamps bool
+	vertexIDs  bool
+	streams    bool
+	logfmt     bool
+}
+
+// label returns how the vertex is referred to in the output: either its index
+// or, with ShowVertexIDs, its ID. It is always passed to Components as a
+// string, whichever it is.
+func (t *trace) label(v *vertex) string {
+	if !t.format.vertexIDs {
+		return strconv.Itoa(v.index)
+	}
+
+	return shortID(v.Id)
+}
+
+// shortID shortens the vertex ID if it's a digest.
+func shortID(id string) string {
+	dgst := digest.Digest(id)
+	if dgst.Validate() != nil {
+		return id
+	}
+
+	encoded := dgst.Encoded()
+	if len(encoded) > shortDigestLength {
+		encoded = encoded[:shortDigestLength]
+	}
+
+	return encoded
+}
+
+// logLine formats a line of the vertex's logs.
+func (t *trace) logLine(v *vertex, stream progrock.LogStream, ts time.Time, text string) []byte {
+	if t.format.logfmt {
+		return t.logfmtLine(v, stream, ts, text)
+	}
+
+	var delta time.Duration
+	if v.Started != nil {
+		delta = ts.Sub(v.Started.AsTime())
+	}
+
+	dur := duration(t.ui, delta, v.Completed != nil)
+
+	if !t.format.timestamps && !t.format.streams {
+		return []byte(fmt.Sprintf(t.ui.TextLogFormat, t.label(v), dur, text))
+	}
+
+	var prefix strings.Builder
+	if t.format.timestamps {
+		fmt.Fprintf(&prefix, t.ui.TextLogTimestamp, ts.UTC().Format(TimestampFormat))
+		prefix.WriteString(" ")
+	}
+
+	fmt.Fprintf(&prefix, t.ui.TextLogVertex, t.label(v))
+
+	if t.format.streams {
+		prefix.WriteString(" ")
+		fmt.Fprintf(&prefix, t.ui.TextLogStream, streamName(stream))
+	}
+
+	return []byte(fmt.Sprintf("%s %s %s", prefix.String(), dur, text))
+}
+
+// logfmtLine formats a line of the vertex's logs as logfmt, e.g.
+//
+//	time=2023-11-14T22:13:20.000Z vertex=1 stream=stdout msg="hello world"
+func (t *trace) logfmtLine(v *vertex, stream progrock.LogStream, ts time.Time, text string) []byte {
+	pairs := [][2]string{
+		{"time", ts.UTC().Format(TimestampFormat)},
+		{"vertex", t.label(v)},
+		{"name", v.name()},
+		{"stream", streamName(stream)},
+		{"msg", text},
+	}
+
+	var line strings.Builder
+	for i, pair := range pairs {
+		if i > 0 {
+			line.WriteString(" ")
+		}
+
+		line.WriteString(pair[0])
+		line.WriteString("=")
+		line.WriteString(logfmtValue(pair[1]))
+	}
+
+	return []byte(line.String())
+}
+
+// logfmtValue quotes the value if it's empty or has characters that would
+// break parsing.
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"") || !printable([]byte(value)) {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
+
+func streamName(stream progrock.LogStream) string {
+	return strings.ToLower(stream.String())
+}
@@ -12,23 +12,29 @@ import (
 	"github.com/vito/progrock"
 )
 
-// AntiFlicker is used to prevent bouncing between concurrent vertices too
-// aggressively.
+// AntiFlicker is the default amount of time used to prevent bouncing between
+// concurrent vertices too aggressively. See WithAntiFlicker.
 const AntiFlicker = 5 * time.Second
 
-// MaxDelay is the maximum amount of time to favor the current vertex over
-// other active vertices.
+// MaxDelay is the default maximum amount of time to favor the current vertex
+// over other active vertices. See WithMaxDelay.
 const MaxDelay = 10 * time.Second
 
-// MinTimeDelta is the minimum amount of time to require before printing
-// updates to a task.
+// MinTimeDelta is the default minimum amount of time to require before
+// printing updates to a task. See WithMinTimeDelta.
 const MinTimeDelta = 5 * time.Second
 
-// MinProgressDelta is the minimum progress percent to require before printing
-// updates to a task.
+// MinProgressDelta is the default minimum progress percent to require before
+// printing updates to a task. See WithMinProgressDelta.
 const MinProgressDelta = 0.05 // %
 
-const logsBufferSize = 10
+// DefaultHeartbeat is the default interval for printing vertexes that are
+// still running in sequential mode. See WithHeartbeat.
+const DefaultHeartbeat = time.Minute
+
+// DefaultRecapLines is the default number of log lines to show for each
+// failed vertex at the end of the output.
+const DefaultRecapLines = 10
 
 type lastStatus struct {
 	Current   int64
@@ -42,6 +48,31 @@ type textMux struct {
 	last         map[string]lastStatus
 	notFirst     bool
 	showInternal bool
+
+	// CI dialect for folding each vertex's output, and the open section
+	dialect  Dialect
+	section  string
+	sections int
+
+	// minimum level of messages to print, and how many have been printed
+	messageLevel    progrock.MessageLevel
+	messagesPrinted int
+
+	// number of log lines to keep for each vertex to recap failures
+	recapLines int
+
+	// print a table of vertexes on close
+	summary bool
+
+	// print each vertex as one block once it completes, rather than
+	// switching between concurrent vertexes
+	sequential bool
+
+	// scheduler timings
+	antiFlicker      time.Duration
+	maxDelay         time.Duration
+	minTimeDelta     time.Duration
+	minProgressDelta float64
 }
 
 func (p *textMux) printVtx(t *trace, dgst string) {
@@ -59,23 +90,10 @@ func (p *textMux) printVtx(t *trace, dgst string) {
 	}
 
 	if dgst != p.current {
-		if p.current != "" {
-			old := t.verticesById[p.current]
-			if old.logsPartial {
-				fmt.Fprintln(p.w, "")
-			}
-			old.logsOffset = 0
-			old.updates = 0
-			fmt.Fprintf(p.w, p.ui.TextContextSwitched, old.index)
-		}
-
-		if p.notFirst {
-			fmt.Fprintln(p.w, "")
-		} else {
-			p.notFirst = true
-		}
-
-		p.printHeader(v)
+		p.leave(t)
+		p.separate()
+		p.startSection(t, v)
+		p.printHeader(t, v)
 		p.printGroups(v, t)
 	}
 
@@ -94,7 +112,7 @@ func (p *textMux) printVtx(t *trace, dgst string) {
 				progressDelta = float64(task.Current-last.Current) / float64(task.Total)
 			}
 			timeDelta := task.Started.AsTime().Sub(last.Timestamp)
-			if progressDelta < MinProgressDelta && timeDelta < MinTimeDelta {
+			if progressDelta < p.minProgressDelta && timeDelta < p.minTimeDelta {
 				doPrint = false
 			}
 		}
@@ -121,71 +139,261 @@ func (p *textMux) printVtx(t *trace, dgst string) {
 			dur = duration(p.ui, task.Completed.AsTime().Sub(task.Started.AsTime()), task.Completed != nil)
 		}
 
-		fmt.Fprintf(p.w, p.ui.TextVertexTask, v.index, dur, strings.Join(segs, " "))
+		fmt.Fprintf(p.w, p.ui.TextVertexTask, t.label(v), dur, strings.Join(segs, " "))
 		fmt.Fprintln(p.w)
 	}
 	v.taskUpdates = map[string]struct{}{}
 
-	for i, l := range v.logs {
-		if i == 0 {
-			l = l[v.logsOffset:]
-		}
-		fmt.Fprintf(p.w, "%s", []byte(l))
-		if i != len(v.logs)-1 || !v.logsPartial {
-			fmt.Fprintln(p.w, "")
-		}
-		if v.logsBuffer == nil {
-			v.logsBuffer = ring.New(logsBufferSize)
-		}
-		v.logsBuffer.Value = l
-		if !v.logsPartial {
+	for _, l := range v.logs {
+		fmt.Fprintf(p.w, "%s\n", l)
+		if p.recapLines > 0 {
+			if v.logsBuffer == nil {
+				v.logsBuffer = ring.New(p.recapLines)
+			}
+			v.logsBuffer.Value = l
 			v.logsBuffer = v.logsBuffer.Next()
 		}
 	}
-
-	if len(v.logs) > 0 {
-		if v.logsPartial {
-			v.logs = v.logs[len(v.logs)-1:]
-			v.logsOffset = len(v.logs[0])
-		} else {
-			v.logs = nil
-			v.logsOffset = 0
-		}
-	}
+	v.logs = nil
 
 	p.current = dgst
 	if v.Completed != nil {
 		p.current = ""
 		v.updates = 0
 
-		if v.logsPartial {
-			fmt.Fprintln(p.w, "")
-		}
-
-		p.printHeader(v)
+		p.endSection(t, v)
+		p.printHeader(t, v)
 	}
 
 	delete(t.updates, dgst)
 }
 
-func (p *textMux) printHeader(v *vertex) {
+func (p *textMux) printHeader(t *trace, v *vertex) {
 	if v.Canceled {
-		fmt.Fprintf(p.w, p.ui.TextVertexCanceled, v.index, v.name())
+		fmt.Fprintf(p.w, p.ui.TextVertexCanceled, t.label(v), v.name())
 	} else if v.Error != nil {
-		fmt.Fprintf(p.w, p.ui.TextVertexErrored, v.index, v.name(), *v.Error)
+		fmt.Fprintf(p.w, p.ui.TextVertexErrored, t.label(v), v.name(), *v.Error)
 	} else if v.Cached {
-		fmt.Fprintf(p.w, p.ui.TextVertexCached, v.index, v.name())
+		fmt.Fprintf(p.w, p.ui.TextVertexCached, t.label(v), v.name())
 	} else if v.Completed != nil {
-		fmt.Fprintf(p.w, p.ui.TextVertexDone, v.index, v.name())
+		fmt.Fprintf(p.w, p.ui.TextVertexDone, t.label(v), v.name())
 	} else if v.Started != nil {
-		fmt.Fprintf(p.w, p.ui.TextVertexRunning, v.index, v.name())
+		fmt.Fprintf(p.w, p.ui.TextVertexRunning, t.label(v), v.name())
 	} else {
-		fmt.Fprintf(p.w, p.ui.TextVertexDone, v.index, v.name())
+		fmt.Fprintf(p.w, p.ui.TextVertexDone, t.label(v), v.name())
 	}
 
 	fmt.Fprintln(p.w)
 }
 
+// leave switches away from the current vertex, if any, so that other output
+// can be printed.
+func (p *textMux) leave(t *trace) {
+	if p.current == "" {
+		return
+	}
+
+	old := t.verticesById[p.current]
+	old.updates = 0
+	fmt.Fprintf(p.w, p.ui.TextContextSwitched, t.label(old))
+	p.endSection(t, old)
+	p.current = ""
+}
+
+// separate prints a blank line between blocks of output.
+func (p *textMux) separate() {
+	if p.notFirst {
+		fmt.Fprintln(p.w, "")
+	} else {
+		p.notFirst = true
+	}
+}
+
+// printMessages prints the messages received since the last call.
+func (p *textMux) printMessages(t *trace) {
+	var msgs []*progrock.Message
+	for _, msg := range t.messages[p.messagesPrinted:] {
+		if msg.Level >= p.messageLevel {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	p.messagesPrinted = len(t.messages)
+
+	if len(msgs) == 0 {
+		return
+	}
+
+	p.leave(t)
+	p.separate()
+
+	for _, msg := range msgs {
+		p.printMessage(msg)
+	}
+}
+
+// printSummary repeats the messages at the end, so that they aren't lost in
+// the output.
+func (p *textMux) printSummary(t *trace) {
+	var msgs []*progrock.Message
+	for _, msg := range t.messages {
+		if msg.Level >= p.messageLevel {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	if len(msgs) == 0 {
+		return
+	}
+
+	p.separate()
+	fmt.Fprintln(p.w, p.ui.TextMessagesSummary)
+
+	for _, msg := range msgs {
+		p.printMessage(msg)
+	}
+}
+
+func (p *textMux) printMessage(msg *progrock.Message) {
+	switch msg.Level {
+	case progrock.MessageLevel_DEBUG:
+		fmt.Fprintf(p.w, p.ui.TextMessageDebug, msg.Message)
+	case progrock.MessageLevel_WARNING:
+		fmt.Fprintf(p.w, p.ui.TextMessageWarning, msg.Message)
+	case progrock.MessageLevel_ERROR:
+		fmt.Fprintf(p.w, p.ui.TextMessageError, msg.Message)
+	}
+
+	if msg.Code != nil {
+		fmt.Fprintf(p.w, p.ui.TextMessageCode, msg.GetCode())
+	}
+
+	for _, l := range msg.Labels {
+		fmt.Fprintf(p.w, p.ui.TextMessageLabel, l.Name, l.Value)
+	}
+
+	fmt.Fprintln(p.w)
+}
+
+// startSection starts a section for the vertex's output, titled with its
+// group hierarchy and name.
+func (p *textMux) startSection(t *trace, v *vertex) {
+	if p.dialect == nil {
+		return
+	}
+
+	p.sections++
+	p.section = fmt.Sprintf("progrock_%d_%d", v.index, p.sections)
+	p.dialect.StartSection(p.w, t.clock.Now(), p.section, v.path(t))
+}
+
+// endSection ends the open section, if any. If the vertex failed and the
+// dialect can't leave the section expanded, its last logs are printed again
+// so that they're visible.
+func (p *textMux) endSection(t *trace, v *vertex) {
+	if p.section == "" {
+		return
+	}
+
+	failed := v.Completed != nil && v.Error != nil
+	expanded := p.dialect.EndSection(p.w, t.clock.Now(), p.section, failed)
+	p.section = ""
+
+	if failed && !expanded && v.logsBuffer != nil {
+		p.printLastLogs(v)
+	}
+}
+
+// printLastLogs prints the last lines of the vertex's logs again.
+func (p *textMux) printLastLogs(v *vertex) {
+	if v.logsBuffer == nil {
+		return
+	}
+
+	v.logsBuffer.Do(func(l any) {
+		if l != nil {
+			fmt.Fprintf(p.w, "%s\n", l)
+		}
+	})
+}
+
+// printRecap lists the canceled vertexes and repeats the errors and last
+// logs of the failed vertexes, so that they're easy to find at the end of the
+// output.
+func (p *textMux) printRecap(t *trace) {
+	var failed, canceled []*vertex
+	for _, v := range t.verticesById {
+		if v.Vertex == nil || v.Completed == nil || (v.Error == nil && !v.Canceled) {
+			continue
+		}
+
+		if v.Internal && !p.showInternal {
+			continue
+		}
+
+		if v.Canceled {
+			canceled = append(canceled, v)
+		} else {
+			failed = append(failed, v)
+		}
+	}
+
+	byIndex := func(vs []*vertex) {
+		sort.Slice(vs, func(i, j int) bool {
+			return vs[i].index < vs[j].index
+		})
+	}
+
+	byIndex(canceled)
+	byIndex(failed)
+
+	if len(canceled) > 0 {
+		p.separate()
+		fmt.Fprintln(p.w, p.ui.TextRecapCanceled)
+
+		for _, v := range canceled {
+			fmt.Fprintf(p.w, p.ui.TextRecapVertexCanceled, t.label(v), v.path(t), duration(p.ui, v.duration(), true))
+			fmt.Fprintln(p.w)
+		}
+	}
+
+	if len(failed) > 0 {
+		p.separate()
+		fmt.Fprintln(p.w, p.ui.TextRecapFailed)
+
+		for i, v := range failed {
+			if i > 0 {
+				fmt.Fprintln(p.w)
+			}
+
+			fmt.Fprintf(p.w, p.ui.TextRecapVertexFailed, t.label(v), v.path(t), duration(p.ui, v.duration(), true), v.GetError())
+			fmt.Fprintln(p.w)
+			p.printLastLogs(v)
+		}
+	}
+}
+
+// close ends the open section, if any, and prints the summary table, the
+// summary of messages, and the recap of failures.
+func (p *textMux) close(t *trace) {
+	if p.sequential {
+		p.printRunning(t)
+	}
+
+	if p.current != "" {
+		if v, found := t.verticesById[p.current]; found {
+			p.endSection(t, v)
+		}
+	}
+
+	if p.summary {
+		p.printSummaryTable(t)
+	}
+
+	p.printSummary(t)
+	p.printRecap(t)
+}
+
 func (p *textMux) printGroups(v *vertex, t *trace) {
 	for _, gid := range t.memberships[v.Id] {
 		g, found := t.groupsById[gid]
@@ -198,11 +406,47 @@ func (p *textMux) printGroups(v *vertex, t *trace) {
 			continue
 		}
 
-		fmt.Fprintf(p.w, p.ui.TextVertexGroup, v.index, g.name(t))
+		fmt.Fprintf(p.w, p.ui.TextVertexGroup, t.label(v), g.name(t))
+
+		if g.Canceled {
+			fmt.Fprint(p.w, p.ui.TextGroupCanceled)
+		} else if g.Error != nil {
+			fmt.Fprintf(p.w, p.ui.TextGroupErrored, *g.Error)
+		}
+
+		if summary := p.summarize(t, g).String(); summary != "" {
+			fmt.Fprintf(p.w, p.ui.TextGroupSummary, summary)
+		}
+
 		fmt.Fprintln(p.w)
 	}
 }
 
+// summarize rolls up the state of the group's vertexes, including those of
+// its sub-groups.
+func (p *textMux) summarize(t *trace, g *group) progrock.GroupSummary {
+	var summary progrock.GroupSummary
+	for vid, gids := range t.memberships {
+		v, found := t.verticesById[vid]
+		if !found || v.Vertex == nil {
+			continue
+		}
+
+		if v.Internal && !p.showInternal {
+			continue
+		}
+
+		for _, gid := range gids {
+			if t.isInGroupOrChild(gid, g.Id) {
+				summary.Add(v.Vertex)
+				break
+			}
+		}
+	}
+
+	return summary
+}
+
 func sortCompleted(t *trace, m map[string]struct{}) []string {
 	out := make([]string, 0, len(m))
 	for k := range m {
@@ -220,6 +464,13 @@ func sortCompleted(t *trace, m map[string]struct{}) []string {
 }
 
 func (p *textMux) print(t *trace) {
+	p.printMessages(t)
+
+	if p.sequential {
+		p.printSequential(t)
+		return
+	}
+
 	completed := map[string]struct{}{}
 	rest := map[string]struct{}{}
 
@@ -283,7 +534,7 @@ func (p *textMux) print(t *trace) {
 		}
 		tm := now.Sub(*v.lastBlockTime)
 		speed := float64(v.updates) / tm.Seconds()
-		reveal := (tm > MaxDelay || v.GetFocused()) && id != current
+		reveal := (tm > p.maxDelay || v.GetFocused()) && id != current
 		stats[id] = &vtxStat{blockTime: tm, speed: speed, reveal: reveal}
 		sum += speed
 		if reveal || max == "" || stats[max].speed < speed {
@@ -308,12 +559,78 @@ func (p *textMux) print(t *trace) {
 	}
 
 	// fair split between vertexes
-	if 1.0/(1.0-stats[current].share)*AntiFlicker.Seconds() < stats[current].blockTime.Seconds() {
+	if 1.0/(1.0-stats[current].share)*p.antiFlicker.Seconds() < stats[current].blockTime.Seconds() {
 		p.printVtx(t, max)
 		return
 	}
 }
 
+// printSequential prints each completed vertex in one block, leaving the
+// output of running vertexes buffered until they complete.
+func (p *textMux) printSequential(t *trace) {
+	completed := map[string]struct{}{}
+	for id := range t.updates {
+		if v, ok := t.verticesById[id]; ok && v.Completed != nil {
+			completed[id] = struct{}{}
+		}
+	}
+
+	for _, dgst := range sortCompleted(t, completed) {
+		p.printVtx(t, dgst)
+	}
+}
+
+// printRunning prints the buffered output of vertexes that are still
+// running, so that it isn't lost when the Writer is closed.
+func (p *textMux) printRunning(t *trace) {
+	for _, v := range p.running(t) {
+		p.printVtx(t, v.Id)
+	}
+}
+
+// heartbeat prints the vertexes that have been running for at least the
+// given interval, so that long vertexes don't look stuck in sequential mode.
+func (p *textMux) heartbeat(t *trace, interval time.Duration) {
+	now := t.clock.Now()
+
+	var printed bool
+	for _, v := range p.running(t) {
+		if v.Internal && !p.showInternal {
+			continue
+		}
+
+		elapsed := now.Sub(v.Started.AsTime())
+		if elapsed < interval {
+			continue
+		}
+
+		if !printed {
+			p.separate()
+			printed = true
+		}
+
+		fmt.Fprintf(p.w, p.ui.TextVertexHeartbeat, t.label(v), v.name(), duration(p.ui, elapsed, false))
+		fmt.Fprintln(p.w)
+	}
+}
+
+// running returns the vertexes that have started and not completed, in the
+// order they were first seen.
+func (p *textMux) running(t *trace) []*vertex {
+	var running []*vertex
+	for _, v := range t.verticesById {
+		if v.Vertex != nil && v.Started != nil && v.Completed == nil {
+			running = append(running, v)
+		}
+	}
+
+	sort.Slice(running, func(i, j int) bool {
+		return running[i].index < running[j].index
+	})
+
+	return running
+}
+
 type vtxStat struct {
 	blockTime time.Duration
 	speed     float64
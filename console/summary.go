@@ -0,0 +1,161 @@
+package console
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vito/progrock"
+)
+
+// slowestSteps is the number of vertexes listed as the slowest in the
+// summary.
+const slowestSteps = 3
+
+// printSummaryTable prints a table of the vertexes sorted by how long they
+// took, followed by totals, for an overview of the run.
+func (p *textMux) printSummaryTable(t *trace) {
+	now := t.clock.Now()
+
+	var vertexes []*vertex
+	for _, v := range t.verticesById {
+		if v.Vertex == nil || (v.Internal && !p.showInternal) {
+			continue
+		}
+
+		vertexes = append(vertexes, v)
+	}
+
+	if len(vertexes) == 0 {
+		return
+	}
+
+	sort.SliceStable(vertexes, func(i, j int) bool {
+		di, dj := vertexes[i].elapsed(now), vertexes[j].elapsed(now)
+		if di != dj {
+			return di > dj
+		}
+
+		return vertexes[i].index < vertexes[j].index
+	})
+
+	p.separate()
+	fmt.Fprintln(p.w, p.ui.TextSummary)
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERTEX\tNAME\tSTATE\tCACHED\tDURATION\tGROUP")
+
+	var summary progrock.GroupSummary
+	var started, ended time.Time
+	for _, v := range vertexes {
+		summary.Add(v.Vertex)
+
+		if v.Started != nil {
+			if s := v.Started.AsTime(); started.IsZero() || s.Before(started) {
+				started = s
+			}
+
+			end := now
+			if v.Completed != nil {
+				end = v.Completed.AsTime()
+			}
+
+			if end.After(ended) {
+				ended = end
+			}
+		}
+
+		var cached string
+		if v.Cached {
+			cached = "yes"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.label(v),
+			v.name(),
+			state(v.Vertex),
+			cached,
+			seconds(v.elapsed(now)),
+			v.group(t))
+	}
+
+	tw.Flush()
+
+	fmt.Fprintln(p.w)
+
+	counts := []string{fmt.Sprintf("%d total", summary.Total)}
+	for _, count := range []struct {
+		n     int
+		state string
+	}{
+		{summary.Completed, "completed"},
+		{summary.Failed, "failed"},
+		{summary.Canceled, "canceled"},
+		{summary.Running, "running"},
+		{summary.Total - summary.Completed - summary.Failed - summary.Canceled - summary.Running, "pending"},
+	} {
+		if count.n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", count.n, count.state))
+		}
+	}
+	fmt.Fprintf(p.w, p.ui.TextSummaryTotal, "Vertexes", strings.Join(counts, ", "))
+	fmt.Fprintln(p.w)
+
+	if summary.Completed > 0 {
+		fmt.Fprintf(p.w, p.ui.TextSummaryTotal, "Cache hits", fmt.Sprintf("%d of %d completed (%d%%)",
+			summary.Cached,
+			summary.Completed,
+			summary.Cached*100/summary.Completed))
+		fmt.Fprintln(p.w)
+	}
+
+	if !started.IsZero() {
+		fmt.Fprintf(p.w, p.ui.TextSummaryTotal, "Wall time", seconds(ended.Sub(started)))
+		fmt.Fprintln(p.w)
+	}
+
+	var slowest []string
+	for _, v := range vertexes {
+		if len(slowest) == slowestSteps || v.elapsed(now) == 0 {
+			break
+		}
+
+		slowest = append(slowest, fmt.Sprintf("%s (%s)", v.name(), seconds(v.elapsed(now))))
+	}
+
+	if len(slowest) > 0 {
+		fmt.Fprintf(p.w, p.ui.TextSummaryTotal, "Slowest", strings.Join(slowest, ", "))
+		fmt.Fprintln(p.w)
+	}
+}
+
+// elapsed returns how long the vertex ran, or has been running.
+func (v *vertex) elapsed(now time.Time) time.Duration {
+	if v.Started == nil {
+		return 0
+	}
+
+	if v.Completed == nil {
+		return now.Sub(v.Started.AsTime())
+	}
+
+	return v.duration()
+}
+
+// state returns the state of the vertex as shown in the summary.
+func state(vtx *progrock.Vertex) string {
+	switch {
+	case vtx.Canceled:
+		return "canceled"
+	case vtx.Error != nil:
+		return "failed"
+	case vtx.Completed != nil:
+		return "completed"
+	case vtx.Started != nil:
+		return "running"
+	default:
+		return "pending"
+	}
+}
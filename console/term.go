@@ -0,0 +1,213 @@
+package console
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/vito/progrock"
+	"github.com/vito/progrock/ui"
+	"github.com/vito/vt100"
+)
+
+// logTerm is a terminal interpreting one of a vertex's log streams.
+type logTerm struct {
+	stream progrock.LogStream
+
+	// created on demand, for logs that aren't plain lines
+	term *vt100.VT100
+
+	// when the current line started, if it has
+	lineStart   time.Time
+	lineStarted bool
+}
+
+// writeLogs interprets the log data with the terminal for its stream, so that
+// carriage returns and cursor movement redraw lines as they would in a
+// terminal, and queues each line once the cursor has moved past it.
+//
+// Lines are timestamped with the log that started them.
+func (v *vertex) writeLogs(t *trace, log *progrock.VertexLog) {
+	lt, found := v.terms[log.Stream]
+	if !found {
+		lt = &logTerm{stream: log.Stream}
+		v.terms[log.Stream] = lt
+	}
+
+	data := log.Data
+	ts := log.Timestamp.AsTime()
+
+	// plain lines don't need interpreting, which is much faster
+	for !lt.lineStarted {
+		i := bytes.IndexByte(data, '\n')
+		if i == -1 || !printable(data[:i]) {
+			break
+		}
+
+		v.logs = append(v.logs, t.logLine(v, lt.stream, ts, strings.TrimRight(string(data[:i]), " ")))
+		data = data[i+1:]
+	}
+
+	if len(data) == 0 {
+		return
+	}
+
+	if lt.term == nil {
+		lt.term = vt100.NewVT100(1, 80)
+		lt.term.AutoResizeX = true
+		lt.term.AutoResizeY = true
+	}
+
+	if !lt.lineStarted {
+		lt.lineStart = ts
+		lt.lineStarted = true
+	}
+
+	lt.term.Write(data)
+
+	settled := lt.term.Cursor.Y
+	if settled > lt.term.Height {
+		settled = lt.term.Height
+	}
+
+	for row := 0; row < settled; row++ {
+		v.logs = append(v.logs, t.logLine(v, lt.stream, lt.lineStart, lt.renderRow(t, row)))
+		lt.lineStart = ts
+	}
+
+	lt.dropRows(settled)
+
+	lt.lineStarted = lt.term.Cursor.X > 0 || lt.pending()
+}
+
+// flushLogs queues the remaining lines of each stream, so that a final line
+// without a trailing linebreak isn't lost.
+func (v *vertex) flushLogs(t *trace) {
+	for _, stream := range []progrock.LogStream{
+		progrock.LogStream_STDIN,
+		progrock.LogStream_STDOUT,
+		progrock.LogStream_STDERR,
+	} {
+		lt, found := v.terms[stream]
+		if !found || lt.term == nil || !lt.lineStarted {
+			continue
+		}
+
+		last := -1
+		for row := range lt.term.Content {
+			if !blank(lt.term.Content[row], lt.term.Format[row]) {
+				last = row
+			}
+		}
+
+		for row := 0; row <= last; row++ {
+			v.logs = append(v.logs, t.logLine(v, lt.stream, lt.lineStart, lt.renderRow(t, row)))
+		}
+
+		lt.dropRows(lt.term.Height)
+		lt.term.Cursor.Y = 0
+		lt.term.Cursor.X = 0
+		lt.lineStarted = false
+	}
+}
+
+// pending returns true if any of the terminal's remaining rows has content.
+func (lt *logTerm) pending() bool {
+	for row := range lt.term.Content {
+		if !blank(lt.term.Content[row], lt.term.Format[row]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dropRows discards rows that have been queued from the top of the terminal.
+func (lt *logTerm) dropRows(n int) {
+	if n == 0 {
+		return
+	}
+
+	term := lt.term
+	term.Cursor.Y -= n
+
+	if n >= term.Height {
+		// keep the last row for the next line rather than allocating a new one
+		n = term.Height - 1
+		clearRow(term.Content[n], term.Format[n])
+	}
+
+	// copy so the discarded rows can be garbage collected
+	term.Content = append([][]rune(nil), term.Content[n:]...)
+	term.Format = append([][]vt100.Format(nil), term.Format[n:]...)
+	term.Height -= n
+}
+
+// renderRow renders the row of the terminal.
+func (lt *logTerm) renderRow(t *trace, row int) string {
+	line := lt.term.Content[row]
+	formats := lt.term.Format[row]
+
+	// trim trailing blanks left over from the terminal's width
+	end := len(line)
+	for end > 0 && line[end-1] == ' ' && plain(formats[end-1]) {
+		end--
+	}
+
+	var text strings.Builder
+	var lastFormat vt100.Format
+	for col, r := range line[:end] {
+		f := formats[col]
+		if plain(f) {
+			f = vt100.Format{}
+		}
+
+		if f != lastFormat && !t.format.stripANSI {
+			lastFormat = f
+			text.WriteString(ui.RenderFormat(f))
+		}
+
+		text.WriteRune(r)
+	}
+
+	if lastFormat != (vt100.Format{}) {
+		text.WriteString(ui.RenderFormat(vt100.Format{}))
+	}
+
+	return text.String()
+}
+
+// printable returns true if the text has no control characters.
+func printable(text []byte) bool {
+	for _, b := range text {
+		if b < ' ' || b == 0x7f {
+			return false
+		}
+	}
+
+	return true
+}
+
+// plain returns true if the format is the default, which it is after a
+// reset, too.
+func plain(f vt100.Format) bool {
+	f.Reset = false
+	return f == vt100.Format{}
+}
+
+func clearRow(line []rune, formats []vt100.Format) {
+	for col := range line {
+		line[col] = ' '
+		formats[col] = vt100.Format{}
+	}
+}
+
+func blank(line []rune, formats []vt100.Format) bool {
+	for col, r := range line {
+		if r != ' ' || !plain(formats[col]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,16 +1,14 @@
 package console
 
 import (
-	"bytes"
 	"container/ring"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/jonboulle/clockwork"
-	"github.com/muesli/termenv"
 	"github.com/vito/progrock"
-	"github.com/vito/vt100"
+	"github.com/vito/progrock/ui"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -18,11 +16,13 @@ import (
 type trace struct {
 	clock        clockwork.Clock
 	ui           Components
+	format       logFormat
 	groupsById   map[string]*group
 	verticesById map[string]*vertex
 	nextIndex    int
 	updates      map[string]struct{}
 	memberships  map[string][]string
+	messages     []*progrock.Message
 }
 
 type vertex struct {
@@ -36,16 +36,14 @@ type vertex struct {
 	index       int
 
 	logs          [][]byte
-	logsPartial   bool
-	logsOffset    int
 	logsBuffer    *ring.Ring // stores last logs to print them on error
 	prev          *progrock.Vertex
 	lastBlockTime *time.Time
 	updates       int
 	taskUpdates   map[string]struct{}
 
-	term      *vt100.VT100
-	termBytes int
+	// terminals interpreting each log stream
+	terms map[progrock.LogStream]*logTerm
 }
 
 func (v *vertex) name() string {
@@ -60,6 +58,39 @@ func (v *vertex) update() {
 	v.updates++
 }
 
+// path returns the vertex's name prefixed by its group hierarchy.
+func (v *vertex) path(t *trace) string {
+	if group := v.group(t); group != "" {
+		return group + " > " + v.name()
+	}
+
+	return v.name()
+}
+
+// group returns the hierarchy of the vertex's first group, or an empty
+// string if it is only in the root group.
+func (v *vertex) group(t *trace) string {
+	for _, gid := range t.memberships[v.Id] {
+		g, found := t.groupsById[gid]
+		if !found || g.Name == progrock.RootGroup {
+			continue
+		}
+
+		return g.name(t)
+	}
+
+	return ""
+}
+
+// duration returns how long the vertex ran.
+func (v *vertex) duration() time.Duration {
+	if v.Started == nil || v.Completed == nil {
+		return 0
+	}
+
+	return v.Completed.AsTime().Sub(v.Started.AsTime())
+}
+
 type group struct {
 	*progrock.Group
 }
@@ -80,10 +111,28 @@ func (g *group) name(t *trace) string {
 	return name
 }
 
-func newTrace(ui Components, clock clockwork.Clock) *trace {
+// isInGroupOrChild returns true if the group is the needle or one of its
+// sub-groups.
+func (t *trace) isInGroupOrChild(gid, needle string) bool {
+	for {
+		if gid == needle {
+			return true
+		}
+
+		g, found := t.groupsById[gid]
+		if !found || g.Parent == nil {
+			return false
+		}
+
+		gid = *g.Parent
+	}
+}
+
+func newTrace(ui Components, clock clockwork.Clock, format logFormat) *trace {
 	return &trace{
 		clock:        clock,
 		ui:           ui,
+		format:       format,
 		verticesById: make(map[string]*vertex),
 		groupsById:   make(map[string]*group),
 		updates:      make(map[string]struct{}),
@@ -145,6 +194,7 @@ func (t *trace) update(update *progrock.StatusUpdate) {
 				clock:       t.clock,
 				tasksByName: make(map[string]*progrock.VertexTask),
 				taskUpdates: make(map[string]struct{}),
+				terms:       make(map[progrock.LogStream]*logTerm),
 				index:       t.nextIndex,
 			}
 		}
@@ -178,25 +228,19 @@ func (t *trace) update(update *progrock.StatusUpdate) {
 		if !ok {
 			continue // shouldn't happen
 		}
-		i := 0
-		complete := split(l.Data, '\n', func(dt []byte) {
-			if v.logsPartial && len(v.logs) != 0 && i == 0 {
-				v.logs[len(v.logs)-1] = append(v.logs[len(v.logs)-1], dt...)
-			} else {
-				delta := time.Duration(0)
-				if v.Started != nil {
-					delta = l.Timestamp.AsTime().Sub(v.Started.AsTime())
-				}
-
-				v.logs = append(v.logs, []byte(fmt.Sprintf(t.ui.TextLogFormat, v.index, duration(t.ui, delta, v.Completed != nil), dt)))
-			}
-			i++
-		})
-		v.logsPartial = !complete
+		v.writeLogs(t, l)
 		t.updates[v.Id] = struct{}{}
 		v.update()
 	}
 
+	for _, v := range update.Vertexes {
+		if v.Completed != nil {
+			t.verticesById[v.Id].flushLogs(t)
+		}
+	}
+
+	t.messages = append(t.messages, update.Messages...)
+
 	for _, g := range update.Groups {
 		t.groupsById[g.Id] = &group{Group: g}
 	}
@@ -218,14 +262,20 @@ func (t *trace) update(update *progrock.StatusUpdate) {
 	}
 }
 
-func duration(ui Components, dt time.Duration, completed bool) string {
-	prec := 1
-	sec := dt.Seconds()
-	if sec < 10 {
-		prec = 2
-	} else if sec < 100 {
-		prec = 1
+// flushLogs queues the remaining lines of every vertex, including any
+// written after the vertex completed, so that they're printed on Close.
+func (t *trace) flushLogs() {
+	for id, v := range t.verticesById {
+		v.flushLogs(t)
+
+		if len(v.logs) > 0 {
+			t.updates[id] = struct{}{}
+		}
 	}
+}
+
+func duration(ui Components, dt time.Duration, completed bool) string {
+	sec, prec := precision(dt)
 
 	if completed {
 		return fmt.Sprintf(ui.DoneDuration, sec, prec)
@@ -234,22 +284,23 @@ func duration(ui Components, dt time.Duration, completed bool) string {
 	}
 }
 
-func split(dt []byte, sep byte, fn func([]byte)) bool {
-	if len(dt) == 0 {
-		return false
-	}
-	for {
-		if len(dt) == 0 {
-			return true
-		}
-		idx := bytes.IndexByte(dt, sep)
-		if idx == -1 {
-			fn(dt)
-			return false
-		}
-		fn(dt[:idx])
-		dt = dt[idx+1:]
+// seconds formats the duration like duration, but without brackets or color.
+func seconds(dt time.Duration) string {
+	sec, prec := precision(dt)
+	return fmt.Sprintf("%.[2]*[1]fs", sec, prec)
+}
+
+// precision returns the duration in seconds, and how many decimals to show.
+func precision(dt time.Duration) (float64, int) {
+	prec := 1
+	sec := dt.Seconds()
+	if sec < 10 {
+		prec = 2
+	} else if sec < 100 {
+		prec = 1
 	}
+
+	return sec, prec
 }
 
 func addTime(tm *timestamppb.Timestamp, d time.Duration) *time.Time {
@@ -260,40 +311,118 @@ func addTime(tm *timestamppb.Timestamp, d time.Duration) *time.Time {
 	return &t
 }
 
+// Components are the format strings used to print each part of the output.
+// Those that refer to a vertex take its label as their first argument, which
+// is a string: either the vertex's index or, with ShowVertexIDs, its ID.
 type Components struct {
 	TextContextSwitched           string
 	TextLogFormat                 string
+	TextLogTimestamp              string
+	TextLogVertex                 string
+	TextLogStream                 string
 	TextVertexRunning             string
 	TextVertexCanceled            string
 	TextVertexErrored             string
 	TextVertexCached              string
 	TextVertexDone                string
 	TextVertexDoneDuration        string
+	TextVertexHeartbeat           string
 	TextVertexGroup               string
+	TextGroupSummary              string
+	TextGroupErrored              string
+	TextGroupCanceled             string
 	TextVertexTask                string
 	TextVertexTaskDuration        string
 	TextVertexTaskProgressBound   string
 	TextVertexTaskProgressUnbound string
+	TextMessageDebug              string
+	TextMessageWarning            string
+	TextMessageError              string
+	TextMessageCode               string
+	TextMessageLabel              string
+	TextMessagesSummary           string
+	TextRecapCanceled             string
+	TextRecapVertexCanceled       string
+	TextRecapFailed               string
+	TextRecapVertexFailed         string
+	TextSummary                   string
+	TextSummaryTotal              string
 
 	RunningDuration, DoneDuration string
 }
 
-var vertexID = termenv.String("%d:").Foreground(termenv.ANSIMagenta).String()
-
-var DefaultUI = Components{
-	TextLogFormat:                 vertexID + " %s %s",
-	TextContextSwitched:           vertexID + " ...\n",
-	TextVertexRunning:             vertexID + " %s",
-	TextVertexCanceled:            vertexID + " %s " + termenv.String("CANCELED").Foreground(termenv.ANSIYellow).String(),
-	TextVertexErrored:             vertexID + " %s " + termenv.String("ERROR: %s").Foreground(termenv.ANSIRed).String(),
-	TextVertexCached:              vertexID + " %s " + termenv.String("CACHED").Foreground(termenv.ANSICyan).String(),
-	TextVertexDone:                vertexID + " %s " + termenv.String("DONE").Foreground(termenv.ANSIGreen).String(),
-	TextVertexGroup:               vertexID + " > in " + termenv.String("%s").Foreground(termenv.ANSIBlue).String(),
-	TextVertexTask:                vertexID + " %[3]s %[2]s",
-	TextVertexTaskProgressBound:   "%s / %s",
-	TextVertexTaskProgressUnbound: "%s",
-	TextVertexTaskDuration:        "%.1fs",
-
-	RunningDuration: "[%.[2]*[1]fs]",
-	DoneDuration:    termenv.String("[%.[2]*[1]fs]").Foreground(termenv.ANSIBrightBlack).String(),
+// DefaultUI is the DarkTheme, which uses the terminal's own 16 ANSI colors.
+var DefaultUI = ThemedUI(ui.DarkTheme)
+
+// ThemedUI returns the components colored with the theme, using its Console
+// colors for vertex IDs, group names, and cached and canceled vertexes.
+func ThemedUI(theme ui.Theme) Components {
+	colors := consoleColors(theme)
+
+	vertexID := theme.Color(colors.VertexID, "%s:")
+
+	level := func(color, prefix string) string {
+		return theme.Profile.String(prefix).Foreground(theme.Profile.Color(color)).Bold().String() + " %s"
+	}
+
+	return Components{
+		TextLogFormat:                 vertexID + " %s %s",
+		TextLogTimestamp:              theme.Color(theme.Muted, "%s"),
+		TextLogVertex:                 vertexID,
+		TextLogStream:                 theme.Color(theme.Muted, "%s"),
+		TextContextSwitched:           vertexID + " ...\n",
+		TextVertexRunning:             vertexID + " %s",
+		TextVertexCanceled:            vertexID + " %s " + theme.Color(colors.Canceled, "CANCELED"),
+		TextVertexErrored:             vertexID + " %s " + theme.Color(theme.Failed, "ERROR: %s"),
+		TextVertexCached:              vertexID + " %s " + theme.Color(colors.Cached, "CACHED"),
+		TextVertexDone:                vertexID + " %s " + theme.Color(theme.Completed, "DONE"),
+		TextVertexHeartbeat:           vertexID + " %s " + theme.Color(theme.Running, "still running") + " %s",
+		TextVertexGroup:               vertexID + " > in " + theme.Color(colors.Group, "%s"),
+		TextGroupSummary:              theme.Color(theme.Muted, " (%s)"),
+		TextGroupErrored:              " " + theme.Color(theme.Failed, "ERROR: %s"),
+		TextGroupCanceled:             " " + theme.Color(colors.Canceled, "CANCELED"),
+		TextVertexTask:                vertexID + " %[3]s %[2]s",
+		TextVertexTaskProgressBound:   "%s / %s",
+		TextVertexTaskProgressUnbound: "%s",
+		TextVertexTaskDuration:        "%.1fs",
+		TextMessageDebug:              level(theme.Debug, "DEBUG:"),
+		TextMessageWarning:            level(theme.Warning, "WARNING:"),
+		TextMessageError:              level(theme.Error, "ERROR:"),
+		TextMessageCode:               theme.Color(theme.Muted, " [%s]"),
+		TextMessageLabel:              theme.Color(theme.Muted, " %s=%q"),
+		TextMessagesSummary:           theme.Profile.String("Messages:").Bold().String(),
+		TextRecapCanceled:             theme.Profile.String("Canceled:").Foreground(theme.Profile.Color(colors.Canceled)).Bold().String(),
+		TextRecapVertexCanceled:       vertexID + " %s %s",
+		TextRecapFailed:               theme.Profile.String("Failed:").Foreground(theme.Profile.Color(theme.Failed)).Bold().String(),
+		TextRecapVertexFailed:         vertexID + " %s %s " + theme.Color(theme.Failed, "ERROR: %s"),
+		TextSummary:                   theme.Profile.String("Summary:").Bold().String(),
+		TextSummaryTotal:              theme.Profile.String("%s:").Bold().String() + " %s",
+
+		RunningDuration: "[%.[2]*[1]fs]",
+		DoneDuration:    theme.Color(theme.Muted, "[%.[2]*[1]fs]"),
+	}
+}
+
+// consoleColors returns the theme's Console colors, filling in any that are
+// empty from the rest of the theme.
+func consoleColors(theme ui.Theme) ui.ConsoleColors {
+	colors := theme.Console
+
+	if colors.VertexID == "" {
+		colors.VertexID = theme.Accent
+	}
+
+	if colors.Group == "" && len(theme.Groups) > 0 {
+		colors.Group = theme.Groups[0]
+	}
+
+	if colors.Cached == "" {
+		colors.Cached = theme.Cached
+	}
+
+	if colors.Canceled == "" {
+		colors.Canceled = theme.Canceled
+	}
+
+	return colors
 }
@@ -3,19 +3,36 @@ package console
 import (
 	"io"
 	"sync"
+	"time"
 
 	"github.com/jonboulle/clockwork"
 	"github.com/vito/progrock"
+	"github.com/vito/progrock/ui"
 )
 
 type Writer struct {
 	clock        clockwork.Clock
 	ui           Components
 	showInternal bool
+	dialect      Dialect
+	messageLevel progrock.MessageLevel
+	recapLines   int
+	format       logFormat
+
+	summary          bool
+	sequential       bool
+	heartbeat        time.Duration
+	antiFlicker      time.Duration
+	maxDelay         time.Duration
+	minTimeDelta     time.Duration
+	minProgressDelta float64
 
 	trace *trace
 	mux   *textMux
 	l     sync.Mutex
+
+	// stops the heartbeat
+	stop chan struct{}
 }
 
 type WriterOpt func(*Writer)
@@ -32,29 +49,209 @@ func WithUI(ui Components) WriterOpt {
 	}
 }
 
+// WithTheme colors the output with the theme, using ThemedUI.
+func WithTheme(theme ui.Theme) WriterOpt {
+	return WithUI(ThemedUI(theme))
+}
+
 func ShowInternal(show bool) WriterOpt {
 	return func(w *Writer) {
 		w.showInternal = show
 	}
 }
 
+// WithDialect wraps each vertex's output in the CI provider's collapsible
+// sections. By default the dialect is detected with DetectDialect; pass nil to
+// disable it.
+func WithDialect(dialect Dialect) WriterOpt {
+	return func(w *Writer) {
+		w.dialect = dialect
+	}
+}
+
+// WithMessageLevel sets the minimum level of messages to print. The default
+// is progrock.MessageLevel_WARNING.
+func WithMessageLevel(level progrock.MessageLevel) WriterOpt {
+	return func(w *Writer) {
+		w.messageLevel = level
+	}
+}
+
+// WithRecapLines sets the number of log lines to show for each failed vertex
+// in the recap printed on Close. The default is DefaultRecapLines.
+func WithRecapLines(lines int) WriterOpt {
+	return func(w *Writer) {
+		w.recapLines = lines
+	}
+}
+
+// StripANSI removes colors and other formatting from logs, for log viewers
+// that don't render them.
+func StripANSI(strip bool) WriterOpt {
+	return func(w *Writer) {
+		w.format.stripANSI = strip
+	}
+}
+
+// ShowTimestamps prefixes each log line with the absolute time it was
+// logged, formatted with TimestampFormat.
+func ShowTimestamps(show bool) WriterOpt {
+	return func(w *Writer) {
+		w.format.timestamps = show
+	}
+}
+
+// ShowVertexIDs refers to vertexes by their ID, shortened if it is a digest,
+// rather than by the order they were first seen in, so that output can be
+// correlated across runs.
+func ShowVertexIDs(show bool) WriterOpt {
+	return func(w *Writer) {
+		w.format.vertexIDs = show
+	}
+}
+
+// ShowStreams prefixes each log line with the name of its stream, e.g.
+// stdout or stderr.
+func ShowStreams(show bool) WriterOpt {
+	return func(w *Writer) {
+		w.format.streams = show
+	}
+}
+
+// Logfmt prints log lines in logfmt, with the time, vertex, vertex name,
+// stream, and message as keys, so that they can be parsed by log pipelines.
+func Logfmt(enabled bool) WriterOpt {
+	return func(w *Writer) {
+		w.format.logfmt = enabled
+	}
+}
+
+// ShowSummary prints a table of the vertexes sorted by duration on Close,
+// along with totals, for an overview of the run. It is enabled by default.
+func ShowSummary(show bool) WriterOpt {
+	return func(w *Writer) {
+		w.summary = show
+	}
+}
+
+// Sequential prints each vertex's output in one uninterrupted block once it
+// completes, rather than switching between concurrent vertexes as they
+// print. Vertexes that are still running are printed periodically, as
+// configured by WithHeartbeat.
+func Sequential(sequential bool) WriterOpt {
+	return func(w *Writer) {
+		w.sequential = sequential
+	}
+}
+
+// WithHeartbeat sets the interval for printing vertexes that are still
+// running in sequential mode. The default is DefaultHeartbeat; pass 0 to
+// disable it.
+func WithHeartbeat(interval time.Duration) WriterOpt {
+	return func(w *Writer) {
+		w.heartbeat = interval
+	}
+}
+
+// WithAntiFlicker sets how strongly to avoid bouncing between concurrent
+// vertexes. The default is AntiFlicker.
+func WithAntiFlicker(d time.Duration) WriterOpt {
+	return func(w *Writer) {
+		w.antiFlicker = d
+	}
+}
+
+// WithMaxDelay sets the maximum amount of time to favor the current vertex
+// over other active vertexes. The default is MaxDelay.
+func WithMaxDelay(d time.Duration) WriterOpt {
+	return func(w *Writer) {
+		w.maxDelay = d
+	}
+}
+
+// WithMinTimeDelta sets the minimum amount of time to require before printing
+// updates to a task. The default is MinTimeDelta.
+func WithMinTimeDelta(d time.Duration) WriterOpt {
+	return func(w *Writer) {
+		w.minTimeDelta = d
+	}
+}
+
+// WithMinProgressDelta sets the minimum progress, as a fraction of the total,
+// to require before printing updates to a task. The default is
+// MinProgressDelta.
+func WithMinProgressDelta(delta float64) WriterOpt {
+	return func(w *Writer) {
+		w.minProgressDelta = delta
+	}
+}
+
 func NewWriter(dest io.Writer, opts ...WriterOpt) progrock.Writer {
 	w := &Writer{
 		clock:        clockwork.NewRealClock(),
-		ui:           DefaultUI,
+		ui:           ThemedUI(ui.DetectTheme()),
 		showInternal: false,
+		dialect:      DetectDialect(),
+		messageLevel: progrock.MessageLevel_WARNING,
+		recapLines:   DefaultRecapLines,
+		summary:      true,
+
+		heartbeat:        DefaultHeartbeat,
+		antiFlicker:      AntiFlicker,
+		maxDelay:         MaxDelay,
+		minTimeDelta:     MinTimeDelta,
+		minProgressDelta: MinProgressDelta,
 	}
 
 	for _, opt := range opts {
 		opt(w)
 	}
 
-	w.trace = newTrace(w.ui, w.clock)
-	w.mux = &textMux{w: dest, ui: w.ui, showInternal: w.showInternal}
+	w.trace = newTrace(w.ui, w.clock, w.format)
+	w.mux = &textMux{
+		w:            dest,
+		ui:           w.ui,
+		showInternal: w.showInternal,
+		dialect:      w.dialect,
+		messageLevel: w.messageLevel,
+		recapLines:   w.recapLines,
+		sequential:   w.sequential,
+		summary:      w.summary,
+
+		antiFlicker:      w.antiFlicker,
+		maxDelay:         w.maxDelay,
+		minTimeDelta:     w.minTimeDelta,
+		minProgressDelta: w.minProgressDelta,
+	}
+
+	if w.sequential && w.heartbeat > 0 {
+		w.stop = make(chan struct{})
+		go w.beat(w.clock.NewTicker(w.heartbeat), w.stop)
+	}
 
 	return w
 }
 
+func (w *Writer) beat(ticker clockwork.Ticker, stop <-chan struct{}) {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.Chan():
+			w.l.Lock()
+			select {
+			case <-stop:
+				// closed while waiting for the lock
+			default:
+				w.mux.heartbeat(w.trace, w.heartbeat)
+			}
+			w.l.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
 func (w *Writer) WriteStatus(status *progrock.StatusUpdate) error {
 	w.l.Lock()
 	defer w.l.Unlock()
@@ -65,6 +262,16 @@ func (w *Writer) WriteStatus(status *progrock.StatusUpdate) error {
 }
 
 func (w *Writer) Close() error {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+
+	w.trace.flushLogs()
 	w.mux.print(w.trace)
+	w.mux.close(w.trace)
 	return nil
 }
@@ -12,6 +12,7 @@ import (
 
 	"github.com/opencontainers/go-digest"
 	"github.com/vito/progrock"
+	"github.com/vito/progrock/ui"
 )
 
 func cmdVtx(ctx context.Context, rec *progrock.Recorder, exe string, args ...string) {
@@ -31,10 +32,12 @@ func cmdVtx(ctx context.Context, rec *progrock.Recorder, exe string, args ...str
 
 var focus bool
 var showInternal bool
+var keysPath string
 
 func init() {
 	flag.BoolVar(&focus, "focus", false, "focus mode")
 	flag.BoolVar(&showInternal, "debug", false, "show internal vertices")
+	flag.StringVar(&keysPath, "keys", "", "path to a JSON key map config")
 }
 
 func main() {
@@ -49,7 +52,18 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	prog, stop := progrock.DefaultUI().RenderLoop(cancel, tape, os.Stderr, true)
+	var uiOpts []progrock.UIOpt
+	if keysPath != "" {
+		keys, err := ui.LoadKeyMap(keysPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		uiOpts = append(uiOpts, progrock.WithKeyMap(keys))
+	}
+
+	prog, stop := progrock.DefaultUI(uiOpts...).RenderLoop(cancel, tape, os.Stderr, true)
 	defer stop()
 
 	prog.Send(progrock.StatusInfoMsg{
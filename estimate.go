@@ -0,0 +1,248 @@
+package progrock
+
+import (
+	"math"
+	"time"
+)
+
+// Estimate is an estimate of the overall progress of the vertexes on a Tape.
+type Estimate struct {
+	// Fraction of the work that has been done, from 0 to 1.
+	Done float64
+
+	// Estimated time until all known work is done, or 0 if it can't be
+	// estimated yet.
+	Remaining time.Duration
+}
+
+// Percent returns the fraction of work done as a whole percentage.
+func (est Estimate) Percent() int {
+	return int(math.Floor(est.Done * 100))
+}
+
+// ExpectedDurationFunc returns how long a vertex is expected to take, or false
+// if it isn't known.
+type ExpectedDurationFunc func(*Vertex) (time.Duration, bool)
+
+// WithExpectedDurations configures the Tape to weigh each vertex by how long
+// it is expected to take when estimating progress, and to measure progress of
+// running vertexes against their expected duration.
+func WithExpectedDurations(expected ExpectedDurationFunc) TapeOpt {
+	return tapeOptFunc(func(tape *Tape) {
+		tape.expectedDuration = expected
+	})
+}
+
+// expected returns how long the vertex is expected to take, or 0 if unknown.
+func (tape *Tape) expected(vtx *Vertex) time.Duration {
+	if tape.expectedDuration == nil {
+		return 0
+	}
+
+	d, ok := tape.expectedDuration(vtx)
+	if !ok {
+		return 0
+	}
+
+	return d
+}
+
+const (
+	// how quickly the estimate catches up as progress is made
+	estimateRiseRate = 2 * time.Second
+
+	// how quickly the estimate falls back as more work is discovered, which is
+	// slower to avoid jumping backwards
+	estimateFallRate = 15 * time.Second
+
+	// how far a running vertex can get without completing, so that its
+	// completion always shows some progress
+	maxRunningProgress = 0.95
+
+	// how far along the work must be before estimating time remaining
+	minEstimateProgress = 0.01
+
+	// how close the estimate must get before it stops smoothing
+	estimateSnap = 0.001
+)
+
+// estimateState holds the smoothed estimate as of the last rendered frame.
+type estimateState struct {
+	done float64
+	at   time.Time
+}
+
+// Estimate returns a smoothed estimate of the overall progress, or nil if
+// there are no vertexes yet.
+//
+// Each vertex counts for its expected duration, if known, or for the average
+// of the known durations otherwise. Running vertexes count as partly done
+// based on the Current and Total of their tasks or on their expected
+// duration. Internal vertexes and vertexes excluded by the filter don't
+// count, as with group summaries.
+//
+// Estimate doesn't change the Tape; the smoothing only advances when the
+// Tape is rendered, so that reading the estimate more than once per frame
+// gives the same result.
+func (tape *Tape) Estimate() *Estimate {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+
+	if len(tape.vertexes) == 0 {
+		return nil
+	}
+
+	now := now(tape.clock)
+
+	est := &Estimate{Done: tape.smoothEstimate(now).done}
+
+	if started, found := tape.firstStarted(); found && est.Done >= minEstimateProgress && est.Done < 1 {
+		elapsed := now.Sub(started)
+		est.Remaining = time.Duration(float64(elapsed) * (1 - est.Done) / est.Done)
+	}
+
+	return est
+}
+
+// advanceEstimate advances the smoothed estimate to now. It is called once
+// per rendered frame.
+func (tape *Tape) advanceEstimate(now time.Time) {
+	if len(tape.vertexes) == 0 {
+		return
+	}
+
+	tape.estimate = tape.smoothEstimate(now)
+}
+
+// smoothEstimate returns the smoothed estimate as of now, moving from the
+// last rendered estimate towards the raw estimate.
+func (tape *Tape) smoothEstimate(now time.Time) estimateState {
+	done := tape.rawEstimate(now)
+	if tape.done || done == 1 || tape.estimate.at.IsZero() {
+		return estimateState{done: done, at: now}
+	}
+
+	rate := estimateRiseRate
+	if done < tape.estimate.done {
+		rate = estimateFallRate
+	}
+
+	elapsed := now.Sub(tape.estimate.at)
+	alpha := 1 - math.Exp(-float64(elapsed)/float64(rate))
+
+	smoothed := tape.estimate.done + (done-tape.estimate.done)*alpha
+	if math.Abs(done-smoothed) < estimateSnap {
+		smoothed = done
+	}
+
+	return estimateState{done: smoothed, at: now}
+}
+
+// rawEstimate returns the unsmoothed fraction of work done.
+func (tape *Tape) rawEstimate(now time.Time) float64 {
+	expected := map[string]time.Duration{}
+	if tape.expectedDuration != nil {
+		for id, vtx := range tape.vertexes {
+			if !tape.estimated(vtx) {
+				continue
+			}
+
+			if d, ok := tape.expectedDuration(vtx); ok && d > 0 {
+				expected[id] = d
+			}
+		}
+	}
+
+	// vertexes with no expected duration count as the average
+	defaultWeight := 1.0
+	if len(expected) > 0 {
+		var total time.Duration
+		for _, d := range expected {
+			total += d
+		}
+		defaultWeight = (total / time.Duration(len(expected))).Seconds()
+	}
+
+	var done, total float64
+	for id, vtx := range tape.vertexes {
+		if !tape.estimated(vtx) {
+			continue
+		}
+
+		weight := defaultWeight
+		d, hasExpected := expected[id]
+		if hasExpected {
+			weight = d.Seconds()
+		}
+
+		total += weight
+
+		switch {
+		case vtx.Completed != nil:
+			done += weight
+		case vtx.Started != nil:
+			progress := tape.taskProgress(id)
+			if hasExpected {
+				progress = math.Max(progress, now.Sub(vtx.Started.AsTime()).Seconds()/d.Seconds())
+			}
+
+			done += weight * math.Min(progress, maxRunningProgress)
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return done / total
+}
+
+// estimated returns whether the vertex counts towards the estimate.
+func (tape *Tape) estimated(vtx *Vertex) bool {
+	if vtx.Internal && !tape.showInternal {
+		return false
+	}
+
+	return tape.matchesFilter(vtx)
+}
+
+// taskProgress returns the fraction of progress made by a vertex's tasks that
+// have a Total, or 0 if it has none.
+func (tape *Tape) taskProgress(vertex string) float64 {
+	var current, total int64
+	for _, task := range tape.tasks[vertex] {
+		if task.GetTotal() == 0 {
+			continue
+		}
+
+		total += task.GetTotal()
+		if task.Completed != nil {
+			current += task.GetTotal()
+		} else {
+			current += task.GetCurrent()
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return float64(current) / float64(total)
+}
+
+// firstStarted returns the time the earliest vertex started.
+func (tape *Tape) firstStarted() (time.Time, bool) {
+	var first time.Time
+	for _, vtx := range tape.vertexes {
+		if vtx.Started == nil || !tape.estimated(vtx) {
+			continue
+		}
+
+		started := vtx.Started.AsTime()
+		if first.IsZero() || started.Before(first) {
+			first = started
+		}
+	}
+
+	return first, !first.IsZero()
+}
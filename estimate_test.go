@@ -0,0 +1,182 @@
+package progrock_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/sebdah/goldie/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/vito/progrock"
+)
+
+func TestEstimate(t *testing.T) {
+	t.Run("no vertexes", func(t *testing.T) {
+		tape := progrock.NewTape()
+		require.Nil(t, tape.Estimate())
+	})
+
+	t.Run("counts completed vertexes", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		recorder.Vertex("a", "vertex a").Done(nil)
+		recorder.Vertex("b", "vertex b").Done(nil)
+		recorder.Vertex("c", "vertex c")
+		recorder.Vertex("d", "vertex d")
+
+		require.Equal(t, 50, tape.Estimate().Percent())
+	})
+
+	t.Run("counts task progress", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		recorder.Vertex("a", "vertex a").Done(nil)
+		recorder.Vertex("b", "vertex b").ProgressTask(100, "downloading").Current(50)
+
+		require.Equal(t, 75, tape.Estimate().Percent())
+	})
+
+	t.Run("weighs by expected duration", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(
+			progrock.WithClock(clock),
+			progrock.WithExpectedDurations(func(vtx *progrock.Vertex) (time.Duration, bool) {
+				switch vtx.Id {
+				case "a":
+					return 10 * time.Second, true
+				case "b":
+					return 30 * time.Second, true
+				default:
+					return 0, false
+				}
+			}),
+		)
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+
+		a := recorder.Vertex("a", "vertex a")
+		clock.Advance(10 * time.Second)
+		a.Done(nil)
+		recorder.Vertex("b", "vertex b")
+		clock.Advance(15 * time.Second)
+
+		// a is done (10s), b is halfway (15s of 30s), and c counts as the
+		// average (20s)
+		recorder.Vertex("c", "vertex c")
+
+		est := tape.Estimate()
+		require.InDelta(t, 25.0/60.0, est.Done, 0.001)
+		require.Equal(t, 35*time.Second, est.Remaining.Round(time.Second))
+	})
+
+	t.Run("falls back slowly when work is discovered", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(progrock.WithClock(clock))
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+		recorder.Vertex("a", "vertex a").Done(nil)
+		recorder.Vertex("b", "vertex b")
+		require.Equal(t, 50, tape.Estimate().Percent())
+		require.NoError(t, tape.Render(io.Discard, ui))
+
+		recorder.Vertex("c", "vertex c")
+		recorder.Vertex("d", "vertex d")
+		clock.Advance(time.Second)
+		require.Greater(t, tape.Estimate().Percent(), 45)
+
+		clock.Advance(time.Minute)
+		require.Equal(t, 25, tape.Estimate().Percent())
+	})
+
+	t.Run("catches up as work is done", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(progrock.WithClock(clock))
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+		recorder.Vertex("a", "vertex a").Done(nil)
+		b := recorder.Vertex("b", "vertex b")
+		recorder.Vertex("c", "vertex c")
+		recorder.Vertex("d", "vertex d")
+		require.Equal(t, 25, tape.Estimate().Percent())
+		require.NoError(t, tape.Render(io.Discard, ui))
+
+		b.Done(nil)
+		clock.Advance(time.Second)
+		percent := tape.Estimate().Percent()
+		require.Greater(t, percent, 25)
+		require.Less(t, percent, 50)
+
+		clock.Advance(time.Minute)
+		require.Equal(t, 50, tape.Estimate().Percent())
+	})
+
+	t.Run("only advances when rendered", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(progrock.WithClock(clock))
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+		recorder.Vertex("a", "vertex a").Done(nil)
+		recorder.Vertex("b", "vertex b")
+		require.NoError(t, tape.Render(io.Discard, ui))
+
+		recorder.Vertex("c", "vertex c")
+		recorder.Vertex("d", "vertex d")
+		clock.Advance(time.Second)
+
+		// reading the estimate repeatedly doesn't smooth it any further
+		est := tape.Estimate()
+		for i := 0; i < 10; i++ {
+			require.Equal(t, est, tape.Estimate())
+		}
+
+		require.NoError(t, tape.Render(io.Discard, ui))
+		require.Equal(t, est, tape.Estimate())
+	})
+
+	t.Run("skips internal and filtered vertexes", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		recorder.Vertex("a", "vertex a").Done(nil)
+		recorder.Vertex("b", "vertex b")
+		recorder.Vertex("internal", "internal", progrock.Internal())
+		recorder.Vertex("other", "other")
+		require.Equal(t, 33, tape.Estimate().Percent())
+
+		tape.Filter("vertex")
+		require.Equal(t, 50, tape.Estimate().Percent())
+
+		tape.ShowInternal(true)
+		tape.Filter("")
+		require.Equal(t, 25, tape.Estimate().Percent())
+	})
+
+	t.Run("status", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(progrock.WithClock(clock))
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+		a := recorder.Vertex("a", "vertex a")
+		recorder.Vertex("b", "vertex b")
+		clock.Advance(time.Minute)
+		a.Done(nil)
+
+		buf := new(bytes.Buffer)
+		require.NoError(t, ui.RenderStatus(buf, tape, nil, ""))
+
+		g := goldie.New(t)
+		g.Assert(t, t.Name(), buf.Bytes())
+	})
+
+	t.Run("trailer", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(progrock.WithClock(clock))
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+		a := recorder.Vertex("a", "vertex a")
+		recorder.Vertex("b", "vertex b")
+		clock.Advance(time.Minute)
+		a.Done(nil)
+
+		buf := new(bytes.Buffer)
+		ui.NewModel(tape, func() {}, io.Discard).PrintTrailer(buf)
+
+		g := goldie.New(t)
+		g.Assert(t, t.Name(), buf.Bytes())
+	})
+}
@@ -0,0 +1,151 @@
+package progrock
+
+import (
+	"strings"
+)
+
+// vertexFilter is a parsed filter query. Every term in the query must match
+// for a vertex to be shown.
+//
+// Terms take one of the following forms:
+//
+//	foo               vertex or group name contains "foo" (case-insensitive)
+//	status:failed     vertex has the given status; one of running, completed,
+//	                  cached, failed, or canceled
+//	label:name        vertex is in a group with the given label
+//	label:name=value  vertex is in a group with the given label and value
+type vertexFilter struct {
+	query string
+	terms []filterTerm
+}
+
+type filterTerm func(vtx *Vertex, groups []*Group) bool
+
+// parseFilter parses a filter query, returning nil if it is empty.
+func parseFilter(query string) *vertexFilter {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	filter := &vertexFilter{query: query}
+	for _, field := range fields {
+		filter.terms = append(filter.terms, parseFilterTerm(field))
+	}
+
+	return filter
+}
+
+func parseFilterTerm(field string) filterTerm {
+	if status, ok := cutPrefix(field, "status:"); ok {
+		return func(vtx *Vertex, _ []*Group) bool {
+			return hasStatus(vtx, strings.ToLower(status))
+		}
+	}
+
+	if label, ok := cutPrefix(field, "label:"); ok {
+		name, value, hasValue := strings.Cut(label, "=")
+		return func(_ *Vertex, groups []*Group) bool {
+			for _, g := range groups {
+				for _, l := range g.Labels {
+					if l.Name == name && (!hasValue || l.Value == value) {
+						return true
+					}
+				}
+			}
+			return false
+		}
+	}
+
+	needle := strings.ToLower(field)
+	return func(vtx *Vertex, groups []*Group) bool {
+		if strings.Contains(strings.ToLower(vtx.Name), needle) {
+			return true
+		}
+
+		for _, g := range groups {
+			if strings.Contains(strings.ToLower(g.Name), needle) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Match returns true if the vertex, which belongs to the given groups and
+// their ancestors, matches every term in the filter.
+func (filter *vertexFilter) Match(vtx *Vertex, groups []*Group) bool {
+	for _, term := range filter.terms {
+		if !term(vtx, groups) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasStatus(vtx *Vertex, status string) bool {
+	switch status {
+	case "running":
+		return vtx.Started != nil && vtx.Completed == nil
+	case "completed", "done":
+		return vtx.Completed != nil && vtx.Error == nil
+	case "cached":
+		return vtx.Cached
+	case "failed", "errored":
+		return vtx.Error != nil && !vtx.Canceled
+	case "canceled":
+		return vtx.Canceled
+	default:
+		return false
+	}
+}
+
+// cutPrefix is strings.CutPrefix, which requires Go 1.20.
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+
+	return s[len(prefix):], true
+}
+
+// Filter only shows vertexes matching the query, along with their groups.
+// An empty query shows everything.
+func (tape *Tape) Filter(query string) {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+	tape.filter = parseFilter(query)
+}
+
+// FilterQuery returns the query set by Filter.
+func (tape *Tape) FilterQuery() string {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+
+	if tape.filter == nil {
+		return ""
+	}
+
+	return tape.filter.query
+}
+
+// matchesFilter returns true if the vertex matches the filter, if any.
+func (tape *Tape) matchesFilter(vtx *Vertex) bool {
+	if tape.filter == nil {
+		return true
+	}
+
+	var groups []*Group
+	for gid := range tape.vertex2groups[vtx.Id] {
+		for group, found := tape.groups[gid]; found; group, found = tape.groups[group.GetParent()] {
+			groups = append(groups, group)
+			if group.Parent == nil {
+				break
+			}
+		}
+	}
+
+	return tape.filter.Match(vtx, groups)
+}
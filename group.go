@@ -0,0 +1,56 @@
+package progrock
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GroupSummary is a rollup of the state of a group's vertexes, including the
+// vertexes of its sub-groups.
+type GroupSummary struct {
+	Total     int
+	Running   int
+	Completed int
+	Cached    int
+	Failed    int
+	Canceled  int
+}
+
+// Add counts the vertex towards the summary.
+func (summary *GroupSummary) Add(vtx *Vertex) {
+	summary.Total++
+
+	switch {
+	case vtx.Canceled:
+		summary.Canceled++
+	case vtx.Error != nil:
+		summary.Failed++
+	case vtx.Completed != nil:
+		summary.Completed++
+		if vtx.Cached {
+			summary.Cached++
+		}
+	case vtx.Started != nil:
+		summary.Running++
+	}
+}
+
+// String returns a human-readable summary of the noteworthy counts, e.g.
+// "2 running, 1 failed". It returns an empty string if there is nothing
+// noteworthy.
+func (summary GroupSummary) String() string {
+	var parts []string
+	if summary.Running > 0 {
+		parts = append(parts, fmt.Sprintf("%d running", summary.Running))
+	}
+	if summary.Failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", summary.Failed))
+	}
+	if summary.Canceled > 0 {
+		parts = append(parts, fmt.Sprintf("%d canceled", summary.Canceled))
+	}
+	if summary.Cached > 0 {
+		parts = append(parts, fmt.Sprintf("%d cached", summary.Cached))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,337 @@
+package progrock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// historySamples is the number of recent durations kept for each vertex.
+const historySamples = 10
+
+// historyMaxEntries is the number of vertex IDs, and separately of vertex
+// names, kept in the history. IDs are often content digests which change
+// whenever their inputs change, and names often include arguments, so the
+// least recently updated are discarded once there are too many.
+const historyMaxEntries = 1000
+
+// historyExpiry is how long an entry is kept after it was last updated.
+const historyExpiry = 30 * 24 * time.Hour
+
+// historyLockTimeout is how long Close waits for another run to finish
+// saving the history, and historyLockStale is how old a lock must be before
+// it is assumed to have been left behind by a run that crashed.
+const (
+	historyLockTimeout = 10 * time.Second
+	historyLockStale   = 30 * time.Second
+)
+
+// History is a Writer that records how long each vertex took, keyed by vertex
+// ID and by name, and saves them to a file on Close so that they can be used
+// as expected durations in later runs.
+//
+// Only successful, uncached vertexes are recorded, since failed and cached
+// vertexes say little about how long the work takes.
+//
+// Concurrent runs may share the same file: Close merges the durations
+// recorded in this run into the file's latest content while holding a lock.
+//
+// Pass ExpectedDuration to WithExpectedDurations to show expected durations
+// and flag slow vertexes on a Tape.
+type History struct {
+	path string
+
+	// durations recorded in previous runs
+	history historyFile
+
+	// durations recorded in this run, which are added to the history on Close
+	// so that they don't skew the expected durations of the run itself
+	recorded map[string]recordedDuration
+
+	l sync.Mutex
+}
+
+type recordedDuration struct {
+	name      string
+	duration  time.Duration
+	completed time.Time
+}
+
+type historyFile struct {
+	IDs   map[string]*historyEntry `json:"ids"`
+	Names map[string]*historyEntry `json:"names"`
+}
+
+type historyEntry struct {
+	Samples []time.Duration `json:"samples"`
+
+	// when the last sample was added, used to discard stale entries
+	Updated time.Time `json:"updated"`
+}
+
+// OpenHistory loads the history from the file at path, which need not exist
+// yet.
+func OpenHistory(path string) (*History, error) {
+	file, err := readHistory(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &History{
+		path:     path,
+		history:  file,
+		recorded: map[string]recordedDuration{},
+	}, nil
+}
+
+// WriteStatus records the durations of completed vertexes.
+func (history *History) WriteStatus(status *StatusUpdate) error {
+	history.l.Lock()
+	defer history.l.Unlock()
+
+	for _, vtx := range status.Vertexes {
+		if vtx.Completed == nil || vtx.Started == nil || vtx.Cached || vtx.Error != nil {
+			continue
+		}
+
+		history.recorded[vtx.Id] = recordedDuration{
+			name:      vtx.Name,
+			duration:  vtx.Completed.AsTime().Sub(vtx.Started.AsTime()),
+			completed: vtx.Completed.AsTime(),
+		}
+	}
+
+	return nil
+}
+
+// Close adds the durations recorded in this run to the history and saves it
+// to its file.
+//
+// The file is read again and merged with while holding a lock, so that
+// durations saved by concurrent runs since OpenHistory are not lost.
+func (history *History) Close() error {
+	history.l.Lock()
+	defer history.l.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(history.path), 0755); err != nil {
+		return err
+	}
+
+	unlock, err := lockHistory(history.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := readHistory(history.path)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(history.recorded))
+	for id := range history.recorded {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		rec := history.recorded[id]
+		addSample(file.IDs, id, rec.duration, rec.completed)
+		addSample(file.Names, rec.name, rec.duration, rec.completed)
+	}
+
+	file.prune()
+
+	history.history = file
+	history.recorded = map[string]recordedDuration{}
+
+	content, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so that concurrent runs don't read a
+	// partially written file
+	tmp, err := os.CreateTemp(filepath.Dir(history.path), filepath.Base(history.path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), history.path)
+}
+
+// ExpectedDuration returns the median of the vertex's recent durations,
+// looking it up by ID first and by name second.
+func (history *History) ExpectedDuration(vtx *Vertex) (time.Duration, bool) {
+	history.l.Lock()
+	defer history.l.Unlock()
+
+	entry, found := history.history.IDs[vtx.Id]
+	if !found {
+		entry, found = history.history.Names[vtx.Name]
+	}
+
+	if !found || len(entry.Samples) == 0 {
+		return 0, false
+	}
+
+	return median(entry.Samples), true
+}
+
+// readHistory reads the history file at path, returning an empty history if
+// it doesn't exist.
+func readHistory(path string) (historyFile, error) {
+	file := historyFile{}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return file, err
+	}
+
+	if err == nil {
+		if err := json.Unmarshal(content, &file); err != nil {
+			return file, fmt.Errorf("parse history %s: %w", path, err)
+		}
+	}
+
+	if file.IDs == nil {
+		file.IDs = map[string]*historyEntry{}
+	}
+
+	if file.Names == nil {
+		file.Names = map[string]*historyEntry{}
+	}
+
+	return file, nil
+}
+
+// lockHistory takes the lock for the history file at path, waiting for other
+// runs to release it. The returned func releases the lock.
+func lockHistory(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(historyLockTimeout)
+
+	for {
+		lock, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			lock.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > historyLockStale {
+			// left behind by a run that didn't finish saving
+			os.Remove(lockPath)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("lock history %s: timed out waiting for %s", path, lockPath)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// prune discards entries that haven't been updated within historyExpiry of
+// the most recent update, and the least recently updated IDs and names once
+// there are more than historyMaxEntries of either.
+func (file historyFile) prune() {
+	var latest time.Time
+	for _, entries := range []map[string]*historyEntry{file.IDs, file.Names} {
+		for _, entry := range entries {
+			if entry.Updated.After(latest) {
+				latest = entry.Updated
+			}
+		}
+	}
+
+	for _, entries := range []map[string]*historyEntry{file.IDs, file.Names} {
+		for key, entry := range entries {
+			if latest.Sub(entry.Updated) > historyExpiry {
+				delete(entries, key)
+			}
+		}
+
+		capEntries(entries, historyMaxEntries)
+	}
+}
+
+// capEntries discards the least recently updated entries once there are more
+// than max.
+func capEntries(entries map[string]*historyEntry, max int) {
+	if len(entries) <= max {
+		return
+	}
+
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := entries[keys[i]], entries[keys[j]]
+		if !a.Updated.Equal(b.Updated) {
+			return a.Updated.Before(b.Updated)
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	for _, key := range keys[:len(keys)-max] {
+		delete(entries, key)
+	}
+}
+
+// addSample appends the duration to the entry for key, discarding the oldest
+// sample once there are too many.
+func addSample(entries map[string]*historyEntry, key string, duration time.Duration, updated time.Time) {
+	entry, found := entries[key]
+	if !found {
+		entry = &historyEntry{}
+		entries[key] = entry
+	}
+
+	entry.Samples = append(entry.Samples, duration)
+	if len(entry.Samples) > historySamples {
+		entry.Samples = entry.Samples[len(entry.Samples)-historySamples:]
+	}
+
+	if updated.After(entry.Updated) {
+		entry.Updated = updated
+	}
+}
+
+func median(samples []time.Duration) time.Duration {
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+
+	return sorted[mid]
+}
@@ -0,0 +1,215 @@
+package progrock_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+	"github.com/vito/progrock"
+)
+
+func TestHistory(t *testing.T) {
+	// run records a single run, with each vertex taking the given duration
+	run := func(t *testing.T, path string, durations map[string]time.Duration) {
+		history, err := progrock.OpenHistory(path)
+		require.NoError(t, err)
+
+		clock := clockwork.NewFakeClock()
+		recorder := progrock.NewRecorder(history, progrock.WithClock(clock))
+		for id, d := range durations {
+			vtx := recorder.Vertex(digest.Digest(id), "vertex "+id)
+			clock.Advance(d)
+			vtx.Done(nil)
+		}
+
+		failed := recorder.Vertex("failed", "failed vertex")
+		clock.Advance(time.Minute)
+		failed.Done(fmt.Errorf("nope"))
+
+		cached := recorder.Vertex("cached", "cached vertex")
+		cached.Cached()
+		cached.Done(nil)
+
+		require.NoError(t, recorder.Close())
+	}
+
+	expected := func(t *testing.T, path, id, name string) (time.Duration, bool) {
+		history, err := progrock.OpenHistory(path)
+		require.NoError(t, err)
+		return history.ExpectedDuration(&progrock.Vertex{Id: id, Name: name})
+	}
+
+	t.Run("records durations by ID and name", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "history.json")
+		run(t, path, map[string]time.Duration{"a": 10 * time.Second})
+
+		d, found := expected(t, path, "a", "renamed")
+		require.True(t, found)
+		require.Equal(t, 10*time.Second, d)
+
+		d, found = expected(t, path, "changed", "vertex a")
+		require.True(t, found)
+		require.Equal(t, 10*time.Second, d)
+
+		_, found = expected(t, path, "failed", "failed vertex")
+		require.False(t, found)
+
+		_, found = expected(t, path, "cached", "cached vertex")
+		require.False(t, found)
+	})
+
+	t.Run("expects the median of recent runs", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "history.json")
+		run(t, path, map[string]time.Duration{"a": 10 * time.Second})
+		run(t, path, map[string]time.Duration{"a": 50 * time.Second})
+		run(t, path, map[string]time.Duration{"a": 20 * time.Second})
+
+		d, found := expected(t, path, "a", "vertex a")
+		require.True(t, found)
+		require.Equal(t, 20*time.Second, d)
+	})
+
+	t.Run("saves on close", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "history.json")
+
+		history, err := progrock.OpenHistory(path)
+		require.NoError(t, err)
+
+		clock := clockwork.NewFakeClock()
+		recorder := progrock.NewRecorder(history, progrock.WithClock(clock))
+		vtx := recorder.Vertex("a", "vertex a")
+		clock.Advance(time.Second)
+		vtx.Done(nil)
+
+		_, found := history.ExpectedDuration(&progrock.Vertex{Id: "a", Name: "vertex a"})
+		require.False(t, found)
+
+		require.NoError(t, recorder.Close())
+
+		_, found = history.ExpectedDuration(&progrock.Vertex{Id: "a", Name: "vertex a"})
+		require.True(t, found)
+	})
+
+	t.Run("merges concurrent runs", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "history.json")
+
+		// both runs start before either saves
+		first, err := progrock.OpenHistory(path)
+		require.NoError(t, err)
+		second, err := progrock.OpenHistory(path)
+		require.NoError(t, err)
+
+		for id, history := range map[string]*progrock.History{"a": first, "b": second} {
+			clock := clockwork.NewFakeClock()
+			recorder := progrock.NewRecorder(history, progrock.WithClock(clock))
+			vtx := recorder.Vertex(digest.Digest(id), "vertex "+id)
+			clock.Advance(time.Second)
+			vtx.Done(nil)
+			require.NoError(t, recorder.Close())
+		}
+
+		_, found := expected(t, path, "a", "vertex a")
+		require.True(t, found)
+
+		_, found = expected(t, path, "b", "vertex b")
+		require.True(t, found)
+	})
+
+	t.Run("discards stale entries", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "history.json")
+
+		start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i, id := range []string{"old", "new"} {
+			history, err := progrock.OpenHistory(path)
+			require.NoError(t, err)
+
+			clock := clockwork.NewFakeClockAt(start.Add(time.Duration(i) * 60 * 24 * time.Hour))
+			recorder := progrock.NewRecorder(history, progrock.WithClock(clock))
+			vtx := recorder.Vertex(digest.Digest(id), "vertex "+id)
+			clock.Advance(time.Second)
+			vtx.Done(nil)
+			require.NoError(t, recorder.Close())
+		}
+
+		_, found := expected(t, path, "old", "vertex old")
+		require.False(t, found)
+
+		_, found = expected(t, path, "new", "vertex new")
+		require.True(t, found)
+	})
+
+	t.Run("keeps the most recently updated IDs", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "history.json")
+
+		history, err := progrock.OpenHistory(path)
+		require.NoError(t, err)
+
+		clock := clockwork.NewFakeClock()
+		recorder := progrock.NewRecorder(history, progrock.WithClock(clock))
+		for i := 0; i <= 1000; i++ {
+			vtx := recorder.Vertex(digest.Digest(fmt.Sprintf("%d", i)), "vertex")
+			clock.Advance(time.Second)
+			vtx.Done(nil)
+		}
+		require.NoError(t, recorder.Close())
+
+		_, found := expected(t, path, "0", "renamed")
+		require.False(t, found)
+
+		_, found = expected(t, path, "1000", "renamed")
+		require.True(t, found)
+	})
+
+	t.Run("keeps the most recently updated names", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "history.json")
+
+		history, err := progrock.OpenHistory(path)
+		require.NoError(t, err)
+
+		clock := clockwork.NewFakeClock()
+		recorder := progrock.NewRecorder(history, progrock.WithClock(clock))
+		for i := 0; i <= 1000; i++ {
+			vtx := recorder.Vertex("a", fmt.Sprintf("vertex %d", i))
+			clock.Advance(time.Second)
+			vtx.Done(nil)
+		}
+		require.NoError(t, recorder.Close())
+
+		_, found := expected(t, path, "changed", "vertex 0")
+		require.False(t, found)
+
+		_, found = expected(t, path, "changed", "vertex 1000")
+		require.True(t, found)
+	})
+
+	t.Run("shows expected durations and slow vertexes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "history.json")
+		run(t, path, map[string]time.Duration{
+			"a": 10 * time.Second,
+			"b": 10 * time.Second,
+		})
+
+		history, err := progrock.OpenHistory(path)
+		require.NoError(t, err)
+
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(
+			progrock.WithClock(clock),
+			progrock.WithExpectedDurations(history.ExpectedDuration),
+		)
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+
+		a := recorder.Vertex("a", "vertex a")
+		clock.Advance(30 * time.Second)
+		a.Done(nil)
+
+		recorder.Vertex("b", "vertex b")
+		clock.Advance(5 * time.Second)
+
+		testGolden(t, tape)
+	})
+}
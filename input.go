@@ -0,0 +1,51 @@
+package progrock
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+// lineInput is a minimal single-line text input, used for search and filter
+// prompts.
+type lineInput struct {
+	prompt string
+	value  []rune
+}
+
+// Update applies a key press to the input. It returns true once the input
+// has been submitted or canceled, along with whether it was canceled.
+func (input *lineInput) Update(msg tea.KeyMsg) (done, canceled bool) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		return true, false
+	case tea.KeyEsc, tea.KeyCtrlC:
+		return true, true
+	case tea.KeyBackspace:
+		if len(input.value) > 0 {
+			input.value = input.value[:len(input.value)-1]
+		}
+	case tea.KeyCtrlU:
+		input.value = nil
+	case tea.KeySpace:
+		input.value = append(input.value, ' ')
+	case tea.KeyRunes:
+		input.value = append(input.value, msg.Runes...)
+	}
+
+	return false, false
+}
+
+// Value returns the text entered so far.
+func (input *lineInput) Value() string {
+	return string(input.value)
+}
+
+// SetValue replaces the text entered so far.
+func (input *lineInput) SetValue(value string) {
+	input.value = []rune(value)
+}
+
+// View renders the prompt and the text entered so far, followed by a cursor.
+func (input *lineInput) View() string {
+	return input.prompt + string(input.value) + termenv.String(" ").Reverse().String()
+}
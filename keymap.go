@@ -0,0 +1,42 @@
+package progrock
+
+import "github.com/vito/progrock/ui"
+
+// UIOpt configures a UI.
+type UIOpt interface {
+	applyUI(*UI)
+}
+
+// ModelOpt configures a Model.
+type ModelOpt interface {
+	applyModel(*Model)
+}
+
+// KeyMapOpt sets the key bindings. It may be passed to NewUI, DefaultUI,
+// NewModel, or RenderLoop.
+type KeyMapOpt struct {
+	keys ui.KeyMap
+}
+
+// WithKeyMap sets the key bindings, in place of the default ui.Keys. Use
+// ui.LoadKeyMap to load them from a config file.
+func WithKeyMap(keys ui.KeyMap) KeyMapOpt {
+	return KeyMapOpt{keys}
+}
+
+func (opt KeyMapOpt) applyUI(u *UI) {
+	u.Keys = opt.keys
+	setSpinnerKeys(u.Spinner, opt.keys)
+}
+
+func (opt KeyMapOpt) applyModel(m *Model) {
+	m.keys = opt.keys
+	setSpinnerKeys(m.ui.Spinner, opt.keys)
+}
+
+// setSpinnerKeys passes key bindings along to spinners that handle keys.
+func setSpinnerKeys(spinner ui.Spinner, keys ui.KeyMap) {
+	if rave, ok := spinner.(*ui.Rave); ok {
+		rave.Keys = keys
+	}
+}
@@ -0,0 +1,549 @@
+package progrock
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultDurationBuckets are the histogram buckets, in seconds, used for
+// vertex and task durations unless WithDurationBuckets is given. They range
+// from a tenth of a second to an hour, since builds tend to run far longer
+// than requests.
+var DefaultDurationBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600}
+
+// Metrics is a Writer that keeps Prometheus metrics about the progress
+// stream. It is also an http.Handler that serves the metrics in the text
+// exposition format.
+//
+// Vertex and task metrics are labeled with the path of the group that the
+// vertex joined first, and optionally with labels of the group, as configured
+// by WithMetricLabels.
+//
+// Each attempt of a vertex is counted separately.
+//
+// State about a vertex is only kept until it completes, so memory doesn't grow
+// with the number of vertexes seen by a long-running process.
+type Metrics struct {
+	labels  []string
+	buckets []float64
+
+	started   *counter
+	completed *counter
+	cached    *counter
+	errored   *counter
+	canceled  *counter
+
+	vertexDuration *histogram
+	taskDuration   *histogram
+
+	logBytes *counter
+	messages *counter
+
+	// all of the above, in the order they're served
+	metrics []metric
+
+	// running groups, and the first group of each running vertex
+	groups      map[string]*Group
+	memberships map[string]string
+
+	// running vertexes
+	vertexes map[string]*vertexMetrics
+
+	// recently completed vertexes and their number of previous attempts, so
+	// that repeated updates for them aren't counted again
+	done      map[string]int
+	doneOrder []string
+
+	l sync.Mutex
+}
+
+// rememberDone is the number of recently completed vertexes remembered
+// by Metrics.
+const rememberDone = 1000
+
+// vertexMetrics tracks what has been counted for a vertex's current attempt.
+type vertexMetrics struct {
+	attempt int
+	labels  []string
+	started bool
+	cached  bool
+
+	// names of tasks that have completed
+	tasks map[string]bool
+}
+
+// MetricsOpt is an option for NewMetrics.
+type MetricsOpt func(*Metrics)
+
+// WithMetricLabels labels vertex and task metrics with the values of the
+// given group labels, inherited from parent groups. Label names are sanitized
+// to be valid Prometheus label names, e.g. "dagger.io/pipeline" becomes
+// "dagger_io_pipeline". Names that collide with "group" or with each other
+// are suffixed with a number, e.g. "group_2".
+//
+// Every distinct value creates new series, so only use labels with few
+// values.
+func WithMetricLabels(names ...string) MetricsOpt {
+	return func(m *Metrics) {
+		m.labels = names
+	}
+}
+
+// WithDurationBuckets configures the histogram buckets, in seconds, used for
+// vertex and task durations.
+func WithDurationBuckets(buckets ...float64) MetricsOpt {
+	return func(m *Metrics) {
+		m.buckets = buckets
+	}
+}
+
+// NewMetrics returns a new Metrics with no observations.
+func NewMetrics(opts ...MetricsOpt) *Metrics {
+	m := &Metrics{
+		buckets:     DefaultDurationBuckets,
+		groups:      map[string]*Group{},
+		memberships: map[string]string{},
+		vertexes:    map[string]*vertexMetrics{},
+		done:        map[string]int{},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	buckets := make([]float64, len(m.buckets))
+	copy(buckets, m.buckets)
+	sort.Float64s(buckets)
+
+	labels := metricLabelNames(m.labels)
+
+	m.started = newCounter("progrock_vertexes_started_total", "Number of vertexes started.", labels)
+	m.completed = newCounter("progrock_vertexes_completed_total", "Number of vertexes completed, whether they succeeded or not.", labels)
+	m.cached = newCounter("progrock_vertexes_cached_total", "Number of vertexes that were cached.", labels)
+	m.errored = newCounter("progrock_vertexes_errored_total", "Number of vertexes that failed.", labels)
+	m.canceled = newCounter("progrock_vertexes_canceled_total", "Number of vertexes that were canceled.", labels)
+	m.vertexDuration = newHistogram("progrock_vertex_duration_seconds", "How long vertexes took to complete.", labels, buckets)
+	m.taskDuration = newHistogram("progrock_task_duration_seconds", "How long vertex tasks took to complete.", labels, buckets)
+	m.logBytes = newCounter("progrock_log_bytes_total", "Number of bytes logged by vertexes.", []string{"stream"})
+	m.messages = newCounter("progrock_messages_total", "Number of messages sent.", []string{"level"})
+
+	m.metrics = []metric{
+		m.started,
+		m.completed,
+		m.cached,
+		m.errored,
+		m.canceled,
+		m.vertexDuration,
+		m.taskDuration,
+		m.logBytes,
+		m.messages,
+	}
+
+	return m
+}
+
+// WriteStatus updates the metrics with the status update.
+func (m *Metrics) WriteStatus(status *StatusUpdate) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	// record groups and memberships first so that vertexes in the same update
+	// are labeled accordingly
+	for _, g := range status.Groups {
+		if g.Completed != nil {
+			delete(m.groups, g.Id)
+		} else {
+			m.groups[g.Id] = g
+		}
+	}
+
+	for _, membership := range status.Memberships {
+		for _, id := range membership.Vertexes {
+			if _, done := m.done[id]; done {
+				continue
+			}
+
+			if _, found := m.memberships[id]; !found {
+				m.memberships[id] = membership.Group
+			}
+		}
+	}
+
+	// count tasks before vertexes, since a vertex's state is dropped once it
+	// completes
+	for _, task := range status.Tasks {
+		if task.Started == nil || task.Completed == nil {
+			continue
+		}
+
+		state, found := m.vertexes[task.Vertex]
+		if !found || state.tasks[task.Name] {
+			continue
+		}
+
+		state.tasks[task.Name] = true
+
+		m.taskDuration.observe(state.labels, task.Completed.AsTime().Sub(task.Started.AsTime()).Seconds())
+	}
+
+	for _, vtx := range status.Vertexes {
+		m.observeVertex(vtx)
+	}
+
+	for _, log := range status.Logs {
+		m.logBytes.add([]string{strings.ToLower(log.Stream.String())}, float64(len(log.Data)))
+	}
+
+	for _, msg := range status.Messages {
+		m.messages.add([]string{strings.ToLower(msg.Level.String())}, 1)
+	}
+
+	return nil
+}
+
+// Close does nothing; the metrics are still served afterwards.
+func (m *Metrics) Close() error {
+	return nil
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	buf := new(bytes.Buffer)
+
+	m.l.Lock()
+	for _, metric := range m.metrics {
+		metric.write(buf)
+	}
+	m.l.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// observeVertex counts whatever has happened to the vertex since it was last
+// seen.
+func (m *Metrics) observeVertex(vtx *Vertex) {
+	state, found := m.vertexes[vtx.Id]
+	if !found {
+		if attempts, done := m.done[vtx.Id]; done && len(vtx.Attempts) <= attempts {
+			// the attempt has already been counted
+			return
+		}
+
+		state = &vertexMetrics{
+			attempt: len(vtx.Attempts),
+			labels:  m.vertexLabels(vtx.Id),
+			tasks:   map[string]bool{},
+		}
+
+		m.vertexes[vtx.Id] = state
+	}
+
+	if len(vtx.Attempts) > state.attempt {
+		// a new attempt; count it again
+		*state = vertexMetrics{
+			attempt: len(vtx.Attempts),
+			labels:  state.labels,
+			tasks:   map[string]bool{},
+		}
+	}
+
+	labels := state.labels
+
+	if vtx.Started != nil && !state.started {
+		state.started = true
+		m.started.add(labels, 1)
+	}
+
+	if vtx.Cached && !state.cached {
+		state.cached = true
+		m.cached.add(labels, 1)
+	}
+
+	if vtx.Completed != nil {
+		m.completed.add(labels, 1)
+
+		if vtx.Canceled {
+			m.canceled.add(labels, 1)
+		} else if vtx.Error != nil {
+			m.errored.add(labels, 1)
+		}
+
+		if vtx.Started != nil {
+			m.vertexDuration.observe(labels, vtx.Completed.AsTime().Sub(vtx.Started.AsTime()).Seconds())
+		}
+
+		m.forget(vtx.Id, len(vtx.Attempts))
+	}
+}
+
+// forget drops the state of a completed vertex, remembering only that it
+// completed.
+func (m *Metrics) forget(id string, attempts int) {
+	delete(m.vertexes, id)
+	delete(m.memberships, id)
+
+	if _, found := m.done[id]; !found {
+		if len(m.doneOrder) == rememberDone {
+			delete(m.done, m.doneOrder[0])
+			m.doneOrder = m.doneOrder[1:]
+		}
+
+		m.doneOrder = append(m.doneOrder, id)
+	}
+
+	m.done[id] = attempts
+}
+
+// vertexLabels returns the values of the group and configured labels for the
+// vertex.
+func (m *Metrics) vertexLabels(id string) []string {
+	var path []string
+	values := make([]string, len(m.labels))
+	resolved := make([]bool, len(m.labels))
+
+	for g := m.groups[m.memberships[id]]; g != nil; g = m.groups[g.GetParent()] {
+		if g.Name != RootGroup {
+			path = append([]string{g.Name}, path...)
+		}
+
+		// the nearest group's labels take precedence
+		for i, name := range m.labels {
+			if resolved[i] {
+				continue
+			}
+
+			for _, l := range g.Labels {
+				if l.Name == name {
+					values[i] = l.Value
+					resolved[i] = true
+					break
+				}
+			}
+		}
+	}
+
+	return append([]string{strings.Join(path, " > ")}, values...)
+}
+
+// metricLabelNames returns the label names for vertex and task metrics: the
+// group, followed by the sanitized names of the group labels, numbered to be
+// unique.
+func metricLabelNames(groupLabels []string) []string {
+	names := []string{"group"}
+	taken := map[string]bool{"group": true}
+
+	for _, label := range groupLabels {
+		name := metricLabelName(label)
+		for n := 2; taken[name]; n++ {
+			name = fmt.Sprintf("%s_%d", metricLabelName(label), n)
+		}
+
+		taken[name] = true
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// metricLabelName replaces characters that aren't allowed in Prometheus label
+// names with underscores.
+func metricLabelName(name string) string {
+	sanitized := []rune(name)
+	for i, r := range sanitized {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			sanitized[i] = '_'
+		}
+	}
+
+	return string(sanitized)
+}
+
+type metric interface {
+	write(io.Writer)
+}
+
+type counter struct {
+	name   string
+	help   string
+	labels []string
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+func newCounter(name, help string, labels []string) *counter {
+	return &counter{
+		name:   name,
+		help:   help,
+		labels: labels,
+		series: map[string]*counterSeries{},
+	}
+}
+
+func (c *counter) add(values []string, delta float64) {
+	key := seriesKey(values)
+
+	series, found := c.series[key]
+	if !found {
+		series = &counterSeries{values: values}
+		c.series[key] = series
+	}
+
+	series.value += delta
+}
+
+func (c *counter) write(w io.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+
+	for _, key := range sortedKeys(c.series, func(s *counterSeries) []string { return s.values }) {
+		series := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, series.values), formatValue(series.value))
+	}
+}
+
+type histogram struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+
+	// cumulative count of observations for each bucket
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(name, help string, labels []string, buckets []float64) *histogram {
+	return &histogram{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		series:  map[string]*histogramSeries{},
+	}
+}
+
+func (h *histogram) observe(values []string, value float64) {
+	key := seriesKey(values)
+
+	series, found := h.series[key]
+	if !found {
+		series = &histogramSeries{
+			values: values,
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = series
+	}
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+
+	series.count++
+	series.sum += value
+}
+
+func (h *histogram) write(w io.Writer) {
+	writeHeader(w, h.name, h.help, "histogram")
+
+	labels := append(append([]string(nil), h.labels...), "le")
+
+	for _, key := range sortedKeys(h.series, func(s *histogramSeries) []string { return s.values }) {
+		series := h.series[key]
+		values := append(append([]string(nil), series.values...), "")
+
+		for i, bound := range h.buckets {
+			values[len(values)-1] = formatValue(bound)
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(labels, values), series.counts[i])
+		}
+
+		values[len(values)-1] = formatValue(math.Inf(1))
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(labels, values), series.count)
+
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, series.values), formatValue(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, series.values), series.count)
+	}
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// seriesKey joins label values into a map key. The separator can't appear in
+// valid UTF-8.
+func seriesKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// sortedKeys returns the keys of the series, ordered by their label values.
+func sortedKeys[T any](series map[string]T, values func(T) []string) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := values(series[keys[i]]), values(series[keys[j]])
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+
+		return false
+	})
+
+	return keys
+}
+
+var labelValueEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+)
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + labelValueEscaper.Replace(values[i]) + `"`
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,117 @@
+package progrock_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/sebdah/goldie/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/vito/progrock"
+)
+
+func TestMetrics(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	metrics := progrock.NewMetrics()
+	recorder := progrock.NewRecorder(metrics, progrock.WithClock(clock))
+
+	build := recorder.WithGroup("build")
+
+	compile := build.Vertex("compile", "go build")
+	fmt.Fprintln(compile.Stdout(), "compiling")
+	fmt.Fprintln(compile.Stderr(), "warning: \"quoted\"")
+	task := compile.Task("linking")
+	clock.Advance(2 * time.Second)
+	task.Done(nil)
+	clock.Advance(40 * time.Second)
+	compile.Done(nil)
+
+	cached := build.WithGroup("deps").Vertex("download", "go mod download")
+	cached.Cached()
+	cached.Done(nil)
+
+	flaky := recorder.Vertex("flaky", "flaky test", progrock.WithMaxAttempts(2))
+	clock.Advance(time.Second)
+	flaky.Done(errors.New("nope"))
+	flaky.NewAttempt()
+	clock.Advance(3 * time.Second)
+	flaky.Done(nil)
+
+	canceled := recorder.Vertex("canceled", "canceled")
+	clock.Advance(500 * time.Millisecond)
+	canceled.Done(context.Canceled)
+
+	recorder.Vertex("running", "still running")
+
+	recorder.Warn("careful")
+	recorder.Error("oh no")
+
+	require.NoError(t, recorder.Close())
+
+	testGoldenMetrics(t, metrics)
+}
+
+func TestMetricsEmpty(t *testing.T) {
+	testGoldenMetrics(t, progrock.NewMetrics())
+}
+
+func TestMetricsLabels(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	metrics := progrock.NewMetrics(
+		progrock.WithMetricLabels("dagger.io/arch", "group", "dagger/io/arch"),
+		progrock.WithDurationBuckets(10, 1))
+	recorder := progrock.NewRecorder(metrics, progrock.WithClock(clock))
+
+	build := recorder.WithGroup("build", progrock.WithLabels(
+		&progrock.Label{Name: "dagger.io/arch", Value: "arm64"},
+		&progrock.Label{Name: "group", Value: "compilers"},
+		&progrock.Label{Name: "dagger/io/arch", Value: "aarch64"}))
+
+	// labels are inherited from parent groups
+	compile := build.WithGroup("go").Vertex("compile", "go build")
+	clock.Advance(5 * time.Second)
+	compile.Done(nil)
+
+	testGoldenMetrics(t, metrics)
+}
+
+func TestMetricsRepeatedUpdates(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	metrics := progrock.NewMetrics(progrock.WithDurationBuckets(1))
+	recorder := progrock.NewRecorder(metrics, progrock.WithClock(clock))
+
+	vtx := recorder.Vertex("a", "vertex a")
+	clock.Advance(time.Second)
+	vtx.Done(nil)
+
+	// updates after the vertex completed aren't counted again
+	vtx.Output("sha256:5b2ad8c4a1e2a0e2e3d3b9a3f0e1d7c4b5a6f7e8d9c0b1a2f3e4d5c6b7a8f9e0")
+	vtx.Complete()
+	recorder.Join("a")
+
+	testGoldenMetrics(t, metrics)
+}
+
+func testGoldenMetrics(t *testing.T, metrics *progrock.Metrics) {
+	srv := httptest.NewServer(metrics)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", res.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	g := goldie.New(t)
+	g.Assert(t, t.Name(), body)
+}
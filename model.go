@@ -19,7 +19,7 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/muesli/termenv"
+	"github.com/jonboulle/clockwork"
 	"github.com/vito/progrock/tmpl"
 	"github.com/vito/progrock/ui"
 )
@@ -27,15 +27,23 @@ import (
 type UI struct {
 	Spinner ui.Spinner
 
+	// Key bindings used by models created with NewModel.
+	Keys ui.KeyMap
+
 	width, height int
 
+	theme ui.Theme
+
+	// clock used by RenderVertex, RenderTask, and RenderAttempt
+	clock clockwork.Clock
+
 	tmpl *template.Template
 }
 
-func NewUI(spinner ui.Spinner) *UI {
-	ui := &UI{Spinner: spinner}
+func NewUI(spinner ui.Spinner, opts ...UIOpt) *UI {
+	theme := ui.DarkTheme
+	ui := &UI{Spinner: spinner, Keys: ui.Keys}
 	ui.tmpl = template.New("ui").
-		Funcs(termenv.TemplateFuncs(termenv.ANSI)).
 		Funcs(template.FuncMap{
 			"duration": func(dt time.Duration) string {
 				prec := 1
@@ -49,27 +57,58 @@ func NewUI(spinner ui.Spinner) *UI {
 				return fmt.Sprintf("%.[2]*[1]fs", dt.Seconds(), prec)
 			},
 			"bar": func(current, total int64) string {
-				bar := progress.New(progress.WithSolidFill("2"))
+				bar := progress.New(
+					progress.WithSolidFill(ui.theme.Completed),
+					progress.WithColorProfile(ui.theme.Profile),
+				)
+				bar.Full = ui.theme.Glyphs.BarFull
+				bar.Empty = ui.theme.Glyphs.BarEmpty
 				bar.Width = ui.width / 8
-				bar.EmptyColor = "8"
+				bar.EmptyColor = ui.theme.Muted
 				bar.ShowPercentage = false
 				return bar.ViewAs(float64(current) / float64(total))
 			},
+			"eta": func(dt time.Duration) string {
+				return dt.Round(time.Second).String()
+			},
 			"words": func(s string) string {
 				return strings.Join(strings.Fields(s), " ")
 			},
 		})
+
+	ui.SetTheme(theme)
+
+	for _, opt := range opts {
+		opt.applyUI(ui)
+	}
+
 	return ui
 }
 
-func DefaultUI() *UI {
-	ui := NewUI(ui.NewRave())
+// DefaultUI returns a UI using the built-in templates and a theme detected
+// from the environment with ui.DetectTheme.
+func DefaultUI(opts ...UIOpt) *UI {
+	opts = append([]UIOpt{WithTheme(ui.DetectTheme())}, opts...)
+	ui := NewUI(ui.NewRave(), opts...)
 	if err := ui.ParseFS(tmpl.FS, "*.tmpl"); err != nil {
 		panic(err)
 	}
 	return ui
 }
 
+// Theme returns the theme used for rendering.
+func (ui *UI) Theme() ui.Theme {
+	return ui.theme
+}
+
+// SetTheme sets the theme used for rendering, which is also available to
+// templates through the Theme function.
+func (ui *UI) SetTheme(theme ui.Theme) {
+	ui.theme = theme
+	ui.tmpl.Funcs(theme.TemplateFuncs())
+	setSpinnerFrames(ui.Spinner, theme.Glyphs.Meter)
+}
+
 func (ui *UI) SetWindowSize(width, height int) {
 	ui.width = width
 	ui.height = height
@@ -86,12 +125,110 @@ func (ui *UI) ParseFS(fs fs.FS, globs ...string) error {
 	return nil
 }
 
+// RenderVertex renders the vertex, measuring its duration against the UI's
+// clock if it hasn't completed.
 func (ui *UI) RenderVertex(w io.Writer, v *Vertex) error {
-	return ui.tmpl.Lookup("vertex.tmpl").Execute(w, v)
+	return ui.renderVertex(w, v, now(ui.clock), false, 0)
 }
 
+// RenderTask renders the task, measuring its duration against the UI's clock
+// if it hasn't completed.
 func (ui *UI) RenderTask(w io.Writer, v *VertexTask) error {
-	return ui.tmpl.Lookup("task.tmpl").Execute(w, v)
+	return ui.renderTask(w, v, now(ui.clock))
+}
+
+func (ui *UI) RenderGroup(w io.Writer, group *Group, summary GroupSummary) error {
+	return ui.renderGroup(w, group, summary, false, false)
+}
+
+// RenderAttempt renders the attempt, measuring its duration against the UI's
+// clock if it hasn't completed.
+func (ui *UI) RenderAttempt(w io.Writer, number int, attempt *VertexAttempt) error {
+	return ui.renderAttempt(w, number, attempt, now(ui.clock))
+}
+
+// vertexAt, taskAt, and attemptAt measure the duration of running
+// vertexes, tasks, and attempts relative to a given time, so that a Tape can
+// render using its own clock.
+
+type vertexAt struct {
+	*Vertex
+	now time.Time
+
+	// Selected is true if the vertex is selected by the user.
+	Selected bool
+
+	// Expected is how long the vertex is expected to take, or 0 if unknown.
+	Expected time.Duration
+}
+
+func (v vertexAt) Duration() time.Duration {
+	return v.DurationAt(v.now)
+}
+
+const (
+	// how many times longer than expected a vertex must take to be flagged as
+	// slow
+	slowFactor = 2
+
+	// vertexes expected to be quicker than this are never flagged as slow,
+	// since small differences are just noise
+	minSlowExpected = time.Second
+)
+
+// Slow returns true if the vertex has taken much longer than expected.
+func (v vertexAt) Slow() bool {
+	return v.Expected >= minSlowExpected && v.Duration() > v.Expected*slowFactor
+}
+
+type taskAt struct {
+	*VertexTask
+	now time.Time
+}
+
+func (t taskAt) Duration() time.Duration {
+	return t.DurationAt(t.now)
+}
+
+type attemptAt struct {
+	*VertexAttempt
+	now time.Time
+}
+
+func (a attemptAt) Duration() time.Duration {
+	return a.DurationAt(a.now)
+}
+
+func (ui *UI) renderVertex(w io.Writer, v *Vertex, now time.Time, selected bool, expected time.Duration) error {
+	return ui.tmpl.Lookup("vertex.tmpl").Execute(w, vertexAt{v, now, selected, expected})
+}
+
+func (ui *UI) renderGroup(w io.Writer, group *Group, summary GroupSummary, selected, collapsed bool) error {
+	return ui.tmpl.Lookup("group.tmpl").Execute(w, struct {
+		*Group
+		Summary   GroupSummary
+		Selected  bool
+		Collapsed bool
+	}{
+		Group:     group,
+		Summary:   summary,
+		Selected:  selected,
+		Collapsed: collapsed,
+	})
+}
+
+func (ui *UI) renderTask(w io.Writer, t *VertexTask, now time.Time) error {
+	return ui.tmpl.Lookup("task.tmpl").Execute(w, taskAt{t, now})
+}
+
+func (ui *UI) renderAttempt(w io.Writer, number int, attempt *VertexAttempt, now time.Time) error {
+	return ui.tmpl.Lookup("attempt.tmpl").Execute(w, struct {
+		Number int
+		attemptAt
+	}{
+		Number:    number,
+		attemptAt: attemptAt{attempt, now},
+	})
 }
 
 func (ui *UI) RenderTerm(w io.Writer, term *ui.Vterm) error {
@@ -100,10 +237,18 @@ func (ui *UI) RenderTerm(w io.Writer, term *ui.Vterm) error {
 }
 
 func (ui *UI) RenderTrailer(w io.Writer, infos []StatusInfo) error {
+	return ui.renderTrailer(w, infos, nil)
+}
+
+// renderTrailer renders the trailer, including the progress estimate if it's
+// non-nil.
+func (ui *UI) renderTrailer(w io.Writer, infos []StatusInfo, estimate *Estimate) error {
 	return ui.tmpl.Lookup("trailer.tmpl").Execute(w, struct {
-		Infos []StatusInfo
+		Infos    []StatusInfo
+		Estimate *Estimate
 	}{
-		Infos: infos,
+		Infos:    infos,
+		Estimate: estimate,
 	})
 }
 
@@ -122,7 +267,7 @@ func (u *UI) RenderStatus(w io.Writer, tape *Tape, infos []StatusInfo, helpView
 }
 
 func (u *UI) RenderFocusStatus(w io.Writer, tape *Tape, infos []StatusInfo, helpView string) error {
-	spinner, _, _ := u.Spinner.ViewFrame(pulse)
+	spinner, _, _ := u.Spinner.ViewFrame(pulseFrames(u.theme.Glyphs.Fade))
 	return u.tmpl.Lookup("focus-status.tmpl").Execute(w, struct {
 		Spinner      string
 		VertexSymbol string
@@ -131,15 +276,19 @@ func (u *UI) RenderFocusStatus(w io.Writer, tape *Tape, infos []StatusInfo, help
 		Help         string
 	}{
 		Spinner:      spinner,
-		VertexSymbol: block,
+		VertexSymbol: u.theme.Glyphs.Block,
 		Tape:         tape,
 		Infos:        infos,
 		Help:         helpView,
 	})
 }
 
-func (ui *UI) RenderLoop(interrupt context.CancelFunc, tape *Tape, w io.Writer, tui bool) (*tea.Program, func()) {
-	model := ui.NewModel(tape, interrupt, w)
+func (ui *UI) RenderLoop(interrupt context.CancelFunc, tape *Tape, w io.Writer, tui bool, modelOpts ...ModelOpt) (*tea.Program, func()) {
+	model := ui.NewModel(tape, interrupt, w, modelOpts...)
+
+	if err := model.keys.Validate(); err != nil {
+		fmt.Fprintf(w, "%s\n", ui.theme.Color(ui.theme.Failed, fmt.Sprintf("invalid key map: %s", err)))
+	}
 
 	opts := []tea.ProgramOption{tea.WithOutput(w)}
 
@@ -157,7 +306,7 @@ func (ui *UI) RenderLoop(interrupt context.CancelFunc, tape *Tape, w io.Writer,
 		defer displaying.Done()
 		_, err := prog.Run()
 		if err != nil {
-			fmt.Fprintf(w, "%s\n", termenv.String(fmt.Sprintf("display error: %s", err)).Foreground(termenv.ANSIRed))
+			fmt.Fprintf(w, "%s\n", ui.theme.Color(ui.theme.Failed, fmt.Sprintf("display error: %s", err)))
 		}
 	}()
 
@@ -169,19 +318,21 @@ func (ui *UI) RenderLoop(interrupt context.CancelFunc, tape *Tape, w io.Writer,
 	}
 }
 
-func (ui *UI) NewModel(tape *Tape, interrupt context.CancelFunc, w io.Writer) *Model {
+func (ui *UI) NewModel(tape *Tape, interrupt context.CancelFunc, w io.Writer, opts ...ModelOpt) *Model {
 	helpModel := help.New()
-	helpModel.Styles.ShortKey = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(termenv.ANSIBrightBlack))
-	helpModel.Styles.ShortDesc = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(termenv.ANSIBrightBlack))
-	helpModel.Styles.ShortSeparator = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(termenv.ANSIBrightBlack))
+	muted := lipgloss.Color(ui.theme.Muted)
+	helpModel.Styles.ShortKey = lipgloss.NewStyle().Foreground(muted)
+	helpModel.Styles.ShortDesc = lipgloss.NewStyle().Foreground(muted)
+	helpModel.Styles.ShortSeparator = lipgloss.NewStyle().Foreground(muted)
 	helpModel.Styles.Ellipsis = helpModel.Styles.ShortSeparator.Copy()
 	helpModel.Styles.FullKey = helpModel.Styles.ShortKey.Copy()
 	helpModel.Styles.FullDesc = helpModel.Styles.ShortDesc.Copy()
 	helpModel.Styles.FullSeparator = helpModel.Styles.ShortSeparator.Copy()
 
-	return &Model{
+	model := &Model{
 		tape: tape,
 		ui:   ui,
+		keys: ui.Keys,
 
 		interrupt: interrupt,
 
@@ -194,6 +345,12 @@ func (ui *UI) NewModel(tape *Tape, interrupt context.CancelFunc, w io.Writer) *M
 
 		help: helpModel,
 	}
+
+	for _, opt := range opts {
+		opt.applyModel(model)
+	}
+
+	return model
 }
 
 type Model struct {
@@ -201,6 +358,8 @@ type Model struct {
 
 	ui *UI
 
+	keys ui.KeyMap
+
 	interrupt func()
 
 	viewport      viewport.Model
@@ -213,6 +372,16 @@ type Model struct {
 	// UI refresh rate
 	fps float64
 
+	// scroll to the selected vertex or group on the next render
+	revealSelection bool
+
+	// full-screen log pager, if open
+	pager *Pager
+
+	// filter prompt, if open, and the filter to restore if it's canceled
+	filterInput    *lineInput
+	previousFilter string
+
 	finished bool
 
 	help help.Model
@@ -234,7 +403,7 @@ func (m *Model) Print(w io.Writer) {
 }
 
 func (m *Model) PrintTrailer(w io.Writer) {
-	if err := m.ui.RenderTrailer(w, m.statusInfos); err != nil {
+	if err := m.ui.renderTrailer(w, m.statusInfos, m.tape.Estimate()); err != nil {
 		fmt.Fprintln(w, "failed to render trailer:", err)
 		return
 	}
@@ -286,12 +455,76 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.pager != nil {
+			m.updatePager(msg)
+			break
+		}
+
+		if m.filterInput != nil {
+			m.updateFilter(msg)
+			break
+		}
+
 		switch {
-		case key.Matches(msg, ui.Keys.Quit):
+		case key.Matches(msg, m.keys.Quit):
 			// don't tea.Quit, let the UI finish
 			m.interrupt()
-		case key.Matches(msg, ui.Keys.Help):
+		case key.Matches(msg, m.keys.Help):
 			m.help.ShowAll = !m.help.ShowAll
+		case key.Matches(msg, m.keys.Up):
+			m.tape.SelectPrevious()
+			m.revealSelection = true
+		case key.Matches(msg, m.keys.Down):
+			m.tape.SelectNext()
+			m.revealSelection = true
+		case key.Matches(msg, m.keys.PageUp):
+			m.selectPage(m.tape.SelectPrevious)
+			m.revealSelection = true
+		case key.Matches(msg, m.keys.PageDown):
+			m.selectPage(m.tape.SelectNext)
+			m.revealSelection = true
+		case key.Matches(msg, m.keys.Home):
+			m.tape.SelectFirst()
+			m.revealSelection = true
+		case key.Matches(msg, m.keys.End):
+			m.tape.SelectLast()
+			m.revealSelection = true
+		case key.Matches(msg, m.keys.Expand):
+			m.tape.ToggleSelected()
+			m.revealSelection = true
+		case key.Matches(msg, m.keys.ExpandAttempts):
+			m.tape.ToggleSelectedAttempts()
+			m.revealSelection = true
+		case key.Matches(msg, m.keys.ToggleInternal):
+			m.tape.ShowInternal(!m.tape.DisplaySettings().ShowInternal)
+			m.render()
+		case key.Matches(msg, m.keys.ToggleFocus):
+			m.tape.Focus(!m.tape.DisplaySettings().Focus)
+			m.render()
+		case key.Matches(msg, m.keys.ToggleEdges):
+			m.tape.VerboseEdges(!m.tape.DisplaySettings().VerboseEdges)
+			m.render()
+		case key.Matches(msg, m.keys.ToggleOutput):
+			m.tape.ShowAllOutput(!m.tape.DisplaySettings().ShowAllOutput)
+			m.render()
+		case key.Matches(msg, m.keys.CycleMessages):
+			m.tape.MessageLevel(nextMessageLevel(m.tape.DisplaySettings().MessageLevel))
+			m.render()
+		case key.Matches(msg, m.keys.Filter):
+			m.previousFilter = m.tape.FilterQuery()
+			m.filterInput = &lineInput{prompt: "/"}
+			m.filterInput.SetValue(m.previousFilter)
+		case key.Matches(msg, m.keys.Open):
+			if vtx, found := m.tape.SelectedVertex(); found {
+				if pager, found := m.tape.OpenPager(vtx.Id); found {
+					pager.SetKeyMap(m.keys)
+					m.pager = pager
+				}
+			}
+		}
+
+		if m.revealSelection {
+			m.render()
 		}
 
 		s, cmd := m.ui.Spinner.Update(msg)
@@ -353,6 +586,119 @@ func (m *Model) render() {
 	if atBottom {
 		m.viewport.GotoBottom()
 	}
+
+	if m.revealSelection {
+		m.revealSelection = false
+
+		if line, ok := m.tape.SelectedLine(); ok {
+			if line < m.viewport.YOffset {
+				m.viewport.SetYOffset(line)
+			} else if line >= m.viewport.YOffset+m.viewportHeight() {
+				m.viewport.SetYOffset(line - m.viewportHeight() + 1)
+			}
+		}
+	}
+}
+
+// keyMap returns the key bindings, with the help for each display toggle
+// showing its current state.
+func (m *Model) keyMap() ui.KeyMap {
+	keys := m.keys
+	settings := m.tape.DisplaySettings()
+
+	toggle := func(binding *key.Binding, on bool) {
+		state := "off"
+		if on {
+			state = "on"
+		}
+
+		help := binding.Help()
+		binding.SetHelp(help.Key, help.Desc+": "+state)
+	}
+
+	toggle(&keys.ToggleInternal, settings.ShowInternal)
+	toggle(&keys.ToggleFocus, settings.Focus)
+	toggle(&keys.ToggleEdges, settings.VerboseEdges)
+	toggle(&keys.ToggleOutput, settings.ShowAllOutput)
+
+	help := keys.CycleMessages.Help()
+	keys.CycleMessages.SetHelp(help.Key, help.Desc+": "+strings.ToLower(settings.MessageLevel.String())+"+")
+
+	return keys
+}
+
+// nextMessageLevel cycles through the message levels, from most verbose to
+// least verbose.
+func nextMessageLevel(level MessageLevel) MessageLevel {
+	switch level {
+	case MessageLevel_DEBUG:
+		return MessageLevel_WARNING
+	case MessageLevel_WARNING:
+		return MessageLevel_ERROR
+	default:
+		return MessageLevel_DEBUG
+	}
+}
+
+// updatePager handles key presses while the pager is open.
+func (m *Model) updatePager(msg tea.KeyMsg) {
+	switch {
+	case m.pager.Searching():
+		m.pager.Update(msg)
+	case key.Matches(msg, m.keys.Back):
+		if m.pager.Update(msg) {
+			m.pager.Close()
+			m.pager = nil
+		}
+	case key.Matches(msg, m.keys.Quit):
+		// don't tea.Quit, let the UI finish
+		m.interrupt()
+	case key.Matches(msg, m.keys.Help):
+		m.help.ShowAll = !m.help.ShowAll
+	default:
+		m.pager.Update(msg)
+	}
+}
+
+// updateFilter handles key presses while the filter prompt is open, updating
+// the filter as the user types.
+func (m *Model) updateFilter(msg tea.KeyMsg) {
+	done, canceled := m.filterInput.Update(msg)
+	if canceled {
+		m.tape.Filter(m.previousFilter)
+	} else {
+		m.tape.Filter(m.filterInput.Value())
+	}
+
+	if done {
+		m.filterInput = nil
+	}
+
+	m.revealSelection = true
+	m.render()
+}
+
+// selectPage moves the selection by roughly the height of the viewport.
+func (m *Model) selectPage(move func()) {
+	start, _ := m.tape.SelectedLine()
+	for {
+		before, _ := m.tape.SelectedLine()
+		move()
+		line, _ := m.tape.SelectedLine()
+		if line == before {
+			// reached the first or last item
+			return
+		}
+
+		dist := line - start
+		if dist < 0 {
+			dist = -dist
+		}
+
+		if dist >= m.viewportHeight() {
+			return
+		}
+	}
 }
 
 func (m *Model) View() string {
@@ -362,7 +708,12 @@ func (m *Model) View() string {
 		return ""
 	}
 
-	helpView := m.help.View(ui.Keys)
+	if m.pager != nil {
+		helpView := m.help.View(ui.PagerKeyMap{KeyMap: m.keys})
+		return m.pager.View(m.ui, m.maxHeight-lipgloss.Height(helpView)+1, helpView)
+	}
+
+	helpView := m.help.View(m.keyMap())
 
 	helpSep := " "
 	widthMinusHelp := m.maxWidth - lipgloss.Width(helpView)
@@ -382,6 +733,10 @@ func (m *Model) View() string {
 			Render(statusBuf.String()),
 	)
 
+	if m.filterInput != nil {
+		footer = lipgloss.JoinVertical(lipgloss.Left, footer, m.filterInput.View())
+	}
+
 	chromeHeight := lipgloss.Height(footer)
 
 	max := m.maxHeight - chromeHeight
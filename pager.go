@@ -0,0 +1,276 @@
+package progrock
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vito/progrock/ui"
+)
+
+// errorPattern matches lines which look like they're reporting an error.
+var errorPattern = regexp.MustCompile(`(?i)\b(error|fail(ed|ure)?|fatal|panic)\b`)
+
+// Pager displays the full output of a single vertex, following new output as
+// it arrives.
+type Pager struct {
+	tape   *Tape
+	vertex string
+	keys   ui.KeyMap
+
+	// copy of the vertex's logs, guarded by tape.l
+	term *ui.Vterm
+
+	// whether to keep scrolling to the bottom as output arrives
+	follow bool
+
+	// search prompt, if active
+	input *lineInput
+
+	// current search and the rows that match it
+	search  *regexp.Regexp
+	query   string
+	matches []int
+	match   int
+}
+
+// OpenPager returns a Pager displaying the output of the vertex, or false if
+// the vertex is unknown.
+func (tape *Tape) OpenPager(vertex string) (*Pager, bool) {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+
+	if _, found := tape.vertexes[vertex]; !found {
+		return nil, false
+	}
+
+	term := tape.newVterm()
+	if _, err := term.Write(tape.vertexLogs(vertex).Snapshot()); err != nil {
+		tape.log(&Message{
+			Level:   MessageLevel_DEBUG,
+			Message: "failed to copy logs to pager",
+			Labels: []*Label{
+				{Name: "vertex", Value: vertex},
+				{Name: "error", Value: err.Error()},
+			},
+		})
+	}
+
+	tape.mirrors[vertex] = term
+
+	return &Pager{
+		tape:   tape,
+		vertex: vertex,
+		keys:   ui.Keys,
+		term:   term,
+		follow: true,
+	}, true
+}
+
+// SelectedVertex returns the vertex selected by the user, if any.
+func (tape *Tape) SelectedVertex() (*Vertex, bool) {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+	vtx, found := tape.vertexes[tape.selected.vertex]
+	return vtx, found
+}
+
+// SetKeyMap sets the key bindings, in place of the default pager.keys.
+func (pager *Pager) SetKeyMap(keys ui.KeyMap) {
+	pager.tape.l.Lock()
+	defer pager.tape.l.Unlock()
+	pager.keys = keys
+	pager.term.Keys = keys
+}
+
+// Close stops the pager from receiving any more output.
+func (pager *Pager) Close() {
+	pager.tape.l.Lock()
+	defer pager.tape.l.Unlock()
+
+	if pager.tape.mirrors[pager.vertex] == pager.term {
+		delete(pager.tape.mirrors, pager.vertex)
+	}
+}
+
+// Searching returns true if the search prompt is active.
+func (pager *Pager) Searching() bool {
+	return pager.input != nil
+}
+
+// Update handles a key press. It returns true if the pager should be closed.
+func (pager *Pager) Update(msg tea.KeyMsg) bool {
+	pager.tape.l.Lock()
+	defer pager.tape.l.Unlock()
+
+	if pager.input != nil {
+		done, canceled := pager.input.Update(msg)
+		if done {
+			if !canceled {
+				pager.setSearch(pager.input.Value())
+				pager.nextMatch(pager.term.Offset)
+			}
+			pager.input = nil
+		}
+		return false
+	}
+
+	switch {
+	case key.Matches(msg, pager.keys.Back):
+		if pager.search != nil {
+			pager.setSearch("")
+		} else {
+			return true
+		}
+	case key.Matches(msg, pager.keys.Follow):
+		pager.follow = !pager.follow
+	case key.Matches(msg, pager.keys.End):
+		pager.follow = true
+	case key.Matches(msg, pager.keys.Up, pager.keys.Down, pager.keys.PageUp, pager.keys.PageDown, pager.keys.Home):
+		pager.term.Update(msg)
+		pager.follow = pager.term.AtBottom()
+	case key.Matches(msg, pager.keys.Search):
+		pager.input = &lineInput{prompt: "/"}
+		pager.input.SetValue(pager.query)
+	case key.Matches(msg, pager.keys.NextMatch):
+		pager.nextMatch(pager.term.Offset + 1)
+	case key.Matches(msg, pager.keys.PrevMatch):
+		pager.prevMatch(pager.term.Offset - 1)
+	case key.Matches(msg, pager.keys.FirstError):
+		if rows := pager.term.Search(errorPattern); len(rows) > 0 {
+			pager.scrollTo(rows[0])
+		}
+	}
+
+	return false
+}
+
+// View renders the vertex and as much of its output as fits in the given
+// height, followed by a status line.
+func (pager *Pager) View(u *UI, height int, helpView string) string {
+	pager.tape.l.Lock()
+	defer pager.tape.l.Unlock()
+
+	buf := new(bytes.Buffer)
+
+	if vtx, found := pager.tape.vertexes[pager.vertex]; found {
+		if err := u.renderVertex(buf, vtx, now(pager.tape.clock), false, pager.tape.expected(vtx)); err != nil {
+			fmt.Fprintln(buf, vtx.Name)
+		}
+	}
+
+	// leave room for the vertex and status line
+	termHeight := height - 2
+	if termHeight < 1 {
+		termHeight = 1
+	}
+	pager.term.SetHeight(termHeight)
+
+	if pager.search != nil {
+		// refresh matches in case more output arrived
+		pager.matches = pager.term.Search(pager.search)
+	}
+
+	if pager.follow {
+		pager.term.ScrollTo(pager.term.UsedHeight())
+	}
+
+	buf.WriteString(pager.term.View())
+
+	// pad out the rest of the screen so the status line stays at the bottom
+	for lines := pager.term.UsedHeight(); lines < pager.term.Height; lines++ {
+		fmt.Fprintln(buf)
+	}
+
+	buf.WriteString(pager.status(u.theme, helpView))
+
+	return buf.String()
+}
+
+func (pager *Pager) status(theme ui.Theme, helpView string) string {
+	if pager.input != nil {
+		return pager.input.View()
+	}
+
+	var parts []string
+
+	parts = append(parts, theme.Color(theme.Muted, fmt.Sprintf("%3.f%%", pager.term.ScrollPercent()*100)))
+
+	if pager.follow {
+		parts = append(parts, theme.Color(theme.Running, "following"))
+	}
+
+	if pager.search != nil {
+		if len(pager.matches) == 0 {
+			parts = append(parts, theme.Color(theme.Failed, fmt.Sprintf("/%s (no matches)", pager.query)))
+		} else {
+			parts = append(parts, theme.Color(theme.Accent, fmt.Sprintf("/%s (%d/%d)", pager.query, pager.match+1, len(pager.matches))))
+		}
+	}
+
+	if helpView != "" {
+		parts = append(parts, helpView)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// setSearch sets the search query, highlighting any matches.
+func (pager *Pager) setSearch(query string) {
+	pager.query = query
+	pager.matches = nil
+	pager.match = 0
+
+	if query == "" {
+		pager.search = nil
+	} else {
+		pager.search = regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+		pager.matches = pager.term.Search(pager.search)
+	}
+
+	pager.term.SetHighlight(pager.search)
+}
+
+// nextMatch scrolls to the first match at or after the given row, wrapping
+// around to the first match.
+func (pager *Pager) nextMatch(row int) {
+	if len(pager.matches) == 0 {
+		return
+	}
+
+	pager.match = 0
+	for i, m := range pager.matches {
+		if m >= row {
+			pager.match = i
+			break
+		}
+	}
+
+	pager.scrollTo(pager.matches[pager.match])
+}
+
+// prevMatch scrolls to the last match at or before the given row, wrapping
+// around to the last match.
+func (pager *Pager) prevMatch(row int) {
+	if len(pager.matches) == 0 {
+		return
+	}
+
+	pager.match = len(pager.matches) - 1
+	for i := len(pager.matches) - 1; i >= 0; i-- {
+		if pager.matches[i] <= row {
+			pager.match = i
+			break
+		}
+	}
+
+	pager.scrollTo(pager.matches[pager.match])
+}
+
+func (pager *Pager) scrollTo(row int) {
+	pager.follow = false
+	pager.term.ScrollTo(row)
+}
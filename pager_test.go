@@ -0,0 +1,123 @@
+package progrock_test
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jonboulle/clockwork"
+	"github.com/sebdah/goldie/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/vito/progrock"
+)
+
+func TestPager(t *testing.T) {
+	t.Run("follows output", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(progrock.WithClock(clock))
+		tape.SetWindowSize(80, 24)
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+
+		vtx := recorder.Vertex("a", "vertex a")
+		for i := 1; i <= 20; i++ {
+			fmt.Fprintf(vtx.Stdout(), "line %d\n", i)
+		}
+
+		pager, found := tape.OpenPager("a")
+		require.True(t, found)
+		defer pager.Close()
+		pager.View(ui, 10, "")
+
+		fmt.Fprintln(vtx.Stdout(), "line 21")
+
+		testGoldenPager(t, pager)
+	})
+
+	t.Run("searching highlights matches", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(progrock.WithClock(clock))
+		tape.SetWindowSize(80, 24)
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+
+		vtx := recorder.Vertex("a", "vertex a")
+		for i := 1; i <= 20; i++ {
+			fmt.Fprintf(vtx.Stdout(), "line %d\n", i)
+		}
+
+		pager, found := tape.OpenPager("a")
+		require.True(t, found)
+		defer pager.Close()
+		pager.View(ui, 10, "")
+
+		pager.Update(tea.KeyMsg{Type: tea.KeyHome})
+		typeKeys(pager, "/line 1")
+		pager.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		require.False(t, pager.Searching())
+
+		typeKeys(pager, "n")
+
+		testGoldenPager(t, pager)
+	})
+
+	t.Run("jumps to the first error", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(progrock.WithClock(clock))
+		tape.SetWindowSize(80, 24)
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+
+		vtx := recorder.Vertex("a", "vertex a")
+		for i := 1; i <= 20; i++ {
+			if i == 5 {
+				fmt.Fprintln(vtx.Stderr(), "main.go:12: error: undefined: foo")
+			}
+			fmt.Fprintf(vtx.Stdout(), "line %d\n", i)
+		}
+		vtx.Done(fmt.Errorf("exit status 1"))
+
+		pager, found := tape.OpenPager("a")
+		require.True(t, found)
+		defer pager.Close()
+		pager.View(ui, 10, "")
+
+		typeKeys(pager, "e")
+
+		testGoldenPager(t, pager)
+	})
+
+	t.Run("esc clears the search before closing", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		recorder.Vertex("a", "vertex a")
+
+		pager, found := tape.OpenPager("a")
+		require.True(t, found)
+		defer pager.Close()
+
+		typeKeys(pager, "/foo")
+		pager.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		require.False(t, pager.Update(tea.KeyMsg{Type: tea.KeyEsc}))
+		require.True(t, pager.Update(tea.KeyMsg{Type: tea.KeyEsc}))
+	})
+
+	t.Run("unknown vertex", func(t *testing.T) {
+		tape := progrock.NewTape()
+		_, found := tape.OpenPager("bogus")
+		require.False(t, found)
+	})
+}
+
+func typeKeys(pager *progrock.Pager, keys string) {
+	for _, r := range keys {
+		if r == ' ' {
+			pager.Update(tea.KeyMsg{Type: tea.KeySpace})
+		} else {
+			pager.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+}
+
+func testGoldenPager(t *testing.T, pager *progrock.Pager) {
+	g := goldie.New(t)
+	g.Assert(t, t.Name(), []byte(pager.View(ui, 10, "")))
+}
@@ -4,18 +4,30 @@ import (
 	"errors"
 	"sync"
 
+	"github.com/jonboulle/clockwork"
 	"google.golang.org/protobuf/proto"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 )
 
-func Pipe() (Reader, Writer) {
+// PipeOpt is an option for creating a Pipe.
+type PipeOpt interface {
+	applyPipe(*unboundedPipe)
+}
+
+// Pipe returns a Reader and Writer pair connected by an unbounded buffer.
+// Status updates are timestamped as they are read.
+func Pipe(opts ...PipeOpt) (Reader, Writer) {
 	pipe := &unboundedPipe{
 		cond: sync.NewCond(&sync.Mutex{}),
 	}
+	for _, o := range opts {
+		o.applyPipe(pipe)
+	}
 	return pipe, pipe
 }
 
 type unboundedPipe struct {
+	clock  clockwork.Clock
 	cond   *sync.Cond
 	buffer []*StatusUpdate
 	closed bool
@@ -50,7 +62,7 @@ func (p *unboundedPipe) ReadStatus() (*StatusUpdate, bool) {
 
 	if value.Received == nil {
 		value = proto.Clone(value).(*StatusUpdate)
-		value.Received = timestamppb.New(Clock.Now())
+		value.Received = timestamppb.New(now(p.clock))
 	}
 
 	p.buffer = p.buffer[1:]
@@ -33,15 +33,59 @@ func (v *Vertex) HasInput(o *Vertex) bool {
 	return false
 }
 
+// Duration returns how long the vertex has been running, measured against
+// the package-level Clock if it hasn't completed.
+//
+// Deprecated: use DurationAt, which doesn't depend on the package-level
+// Clock.
 func (vertex *Vertex) Duration() time.Duration {
-	return dt(vertex.Started, vertex.Completed)
+	return vertex.DurationAt(Clock.Now())
 }
 
+// DurationAt returns how long the vertex ran, or how long it has been running
+// as of now if it hasn't completed.
+func (vertex *Vertex) DurationAt(now time.Time) time.Duration {
+	return dt(vertex.Started, vertex.Completed, now)
+}
+
+// Attempt returns the number of the vertex's current attempt, starting from 1.
+func (vertex *Vertex) Attempt() int {
+	return len(vertex.Attempts) + 1
+}
+
+// Duration returns how long the attempt ran, measured against the
+// package-level Clock if it hasn't completed.
+//
+// Deprecated: use DurationAt, which doesn't depend on the package-level
+// Clock.
+func (attempt *VertexAttempt) Duration() time.Duration {
+	return attempt.DurationAt(Clock.Now())
+}
+
+// DurationAt returns how long the attempt ran, or how long it has been
+// running as of now if it hasn't completed.
+func (attempt *VertexAttempt) DurationAt(now time.Time) time.Duration {
+	return dt(attempt.Started, attempt.Completed, now)
+}
+
+// Duration returns how long the task has been running, measured against the
+// package-level Clock if it hasn't completed.
+//
+// Deprecated: use DurationAt, which doesn't depend on the package-level
+// Clock.
 func (task *VertexTask) Duration() time.Duration {
-	return dt(task.Started, task.Completed)
+	return task.DurationAt(Clock.Now())
+}
+
+// DurationAt returns how long the task ran, or how long it has been running
+// as of now if it hasn't completed.
+func (task *VertexTask) DurationAt(now time.Time) time.Duration {
+	return dt(task.Started, task.Completed, now)
 }
 
-func dt(started, completed *timestamppb.Timestamp) time.Duration {
+// dt returns the duration between started and completed, or between started
+// and now if completed is nil.
+func dt(started, completed *timestamppb.Timestamp, now time.Time) time.Duration {
 	if started == nil {
 		return 0
 	}
@@ -50,7 +94,7 @@ func dt(started, completed *timestamppb.Timestamp) time.Duration {
 	if completed != nil {
 		end = completed.AsTime()
 	} else {
-		end = Clock.Now()
+		end = now
 	}
 
 	return end.Sub(started.AsTime())
@@ -1,7 +1,10 @@
 package progrock
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,14 +14,15 @@ import (
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 )
 
-// Clock is used to determine the current time.
-var Clock = clockwork.NewRealClock()
-
 // Recorder is a Writer that also tracks a current group.
+//
+// It is safe for concurrent use.
 type Recorder struct {
-	w Writer
+	w     Writer
+	clock clockwork.Clock
 
-	Group *Group
+	Group  *Group
+	groupL sync.Mutex
 
 	groups  map[string]*Recorder
 	groupsL sync.Mutex
@@ -30,17 +34,36 @@ type Recorder struct {
 // while sidestepping the issue of figuring out what the "root" name should be.
 const RootGroup = ""
 
+// RecorderOpt is an option for creating a Recorder.
+//
+// Any GroupOpt may be used as a RecorderOpt, in which case it applies to the
+// root group.
+type RecorderOpt interface {
+	applyRecorder(*recorderConfig)
+}
+
+type recorderConfig struct {
+	clock     clockwork.Clock
+	groupOpts []GroupOpt
+}
+
 // NewRecorder creates a new Recorder, which writes to the given Writer.
 //
 // It also initializes the "root" group and sends a progress update for the
 // group.
-func NewRecorder(w Writer, opts ...GroupOpt) *Recorder {
-	return newEmptyRecorder(w).WithGroup(RootGroup, opts...)
+func NewRecorder(w Writer, opts ...RecorderOpt) *Recorder {
+	var cfg recorderConfig
+	for _, o := range opts {
+		o.applyRecorder(&cfg)
+	}
+
+	return newEmptyRecorder(w, cfg.clock).WithGroup(RootGroup, cfg.groupOpts...)
 }
 
-func newEmptyRecorder(w Writer) *Recorder {
+func newEmptyRecorder(w Writer, clock clockwork.Clock) *Recorder {
 	return &Recorder{
 		w:      w,
+		clock:  clock,
 		groups: map[string]*Recorder{},
 	}
 }
@@ -50,7 +73,7 @@ func (recorder *Recorder) Record(status *StatusUpdate) error {
 	clone := proto.Clone(status).(*StatusUpdate)
 
 	if clone.Sent == nil { // normally this isn't set, but respect if present
-		clone.Sent = timestamppb.New(Clock.Now())
+		clone.Sent = timestamppb.New(recorder.now())
 	}
 
 	// perform a deep-copy so buffered writes don't get mutated, similar to
@@ -118,6 +141,10 @@ func (recorder *Recorder) message(msg string, opts ...MessageOpt) {
 // GroupOpt is an option for creating a Group.
 type GroupOpt func(*Group)
 
+func (opt GroupOpt) applyRecorder(cfg *recorderConfig) {
+	cfg.groupOpts = append(cfg.groupOpts, opt)
+}
+
 // WithLabels sets labels on the group.
 func WithLabels(labels ...*Label) GroupOpt {
 	return func(g *Group) {
@@ -174,7 +201,7 @@ func (recorder *Recorder) WithGroup(name string, opts ...GroupOpt) *Recorder {
 	}
 
 	if g.Started == nil {
-		g.Started = timestamppb.New(Clock.Now())
+		g.Started = timestamppb.New(recorder.now())
 	}
 
 	if g.Id == "" {
@@ -185,7 +212,7 @@ func (recorder *Recorder) WithGroup(name string, opts ...GroupOpt) *Recorder {
 		g.Parent = &recorder.Group.Id
 	}
 
-	subRecorder := newEmptyRecorder(recorder.w)
+	subRecorder := newEmptyRecorder(recorder.w, recorder.clock)
 	subRecorder.Group = g
 	subRecorder.sync()
 
@@ -215,19 +242,57 @@ func (recorder *Recorder) Join(vertexes ...digest.Digest) {
 // Complete marks the current group and all sub-groups as complete, and sends a
 // progress update for each.
 func (recorder *Recorder) Complete() {
+	recorder.groupsL.Lock()
+	groups := make([]*Recorder, 0, len(recorder.groups))
 	for _, g := range recorder.groups {
+		groups = append(groups, g)
+	}
+	recorder.groupsL.Unlock()
+
+	for _, g := range groups {
 		g.Complete()
 	}
-	recorder.Group.Completed = timestamppb.New(Clock.Now())
+
+	recorder.groupL.Lock()
+	defer recorder.groupL.Unlock()
+
+	recorder.Group.Completed = timestamppb.New(recorder.now())
 	recorder.sync()
 }
 
+// Done marks the current group and all sub-groups as complete, and sends a
+// progress update for each.
+//
+// If err is non-nil, the current group is marked as errored. If the error is
+// context.Canceled or has it as a string suffix, the group will be marked as
+// canceled instead.
+func (recorder *Recorder) Done(err error) {
+	if err != nil {
+		msg := err.Error()
+		recorder.groupL.Lock()
+		if errors.Is(err, context.Canceled) || strings.HasSuffix(msg, context.Canceled.Error()) {
+			recorder.Group.Canceled = true
+		} else {
+			recorder.Group.Error = &msg
+		}
+		recorder.groupL.Unlock()
+	}
+
+	recorder.Complete()
+}
+
 // Close closes the underlying Writer.
 func (recorder *Recorder) Close() error {
 	return recorder.w.Close()
 }
 
-// sync sends a progress update for the current group.
+// now returns the current time according to the Recorder's clock.
+func (recorder *Recorder) now() time.Time {
+	return now(recorder.clock)
+}
+
+// sync sends a progress update for the current group. It must be called with
+// groupL held, unless the Recorder is not yet shared.
 func (recorder *Recorder) sync() {
 	recorder.Record(&StatusUpdate{
 		Groups: []*Group{recorder.Group},
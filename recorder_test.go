@@ -0,0 +1,118 @@
+package progrock_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+	"github.com/vito/progrock"
+)
+
+// These tests are most useful when run with -race.
+
+func TestVertexRecorderConcurrency(t *testing.T) {
+	tape := progrock.NewTape()
+	recorder := progrock.NewRecorder(tape)
+
+	vtx := recorder.Vertex("a", "vertex a")
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 10; i++ {
+		i := i
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			fmt.Fprintln(vtx.Stdout(), "stdout", i)
+		}()
+		go func() {
+			defer wg.Done()
+			fmt.Fprintln(vtx.Stderr(), "stderr", i)
+		}()
+		go func() {
+			defer wg.Done()
+			vtx.Output(digest.Digest(fmt.Sprintf("output-%d", i)))
+		}()
+		go func() {
+			defer wg.Done()
+			vtx.Cached()
+		}()
+	}
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		vtx.Error(fmt.Errorf("nope"))
+	}()
+	go func() {
+		defer wg.Done()
+		vtx.NewAttempt()
+	}()
+
+	wg.Wait()
+
+	vtx.Done(nil)
+
+	require.Len(t, vtx.Vertex.Outputs, 10)
+	require.NotNil(t, vtx.Vertex.Completed)
+}
+
+func TestTaskRecorderConcurrency(t *testing.T) {
+	tape := progrock.NewTape()
+	recorder := progrock.NewRecorder(tape)
+
+	vtx := recorder.Vertex("a", "vertex a")
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 10; i++ {
+		task := vtx.ProgressTask(100, "task %d", i)
+
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			task.Start()
+		}()
+		go func() {
+			defer wg.Done()
+			for cur := int64(0); cur <= 100; cur += 10 {
+				task.Progress(cur, 100)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for cur := int64(0); cur <= 100; cur += 10 {
+				task.Current(cur)
+			}
+		}()
+		defer task.Done(nil)
+	}
+
+	wg.Wait()
+}
+
+func TestRecorderConcurrency(t *testing.T) {
+	tape := progrock.NewTape()
+	recorder := progrock.NewRecorder(tape)
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 10; i++ {
+		i := i
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			recorder.WithGroup(fmt.Sprintf("group %d", i)).
+				Vertex(digest.Digest(fmt.Sprintf("vtx-%d", i)), "vertex").
+				Done(nil)
+		}()
+		go func() {
+			defer wg.Done()
+			recorder.Complete()
+		}()
+	}
+
+	wg.Wait()
+
+	recorder.Done(nil)
+	require.NotNil(t, recorder.Group.Completed)
+}
@@ -0,0 +1,454 @@
+package progrock
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/vito/progrock/ui"
+)
+
+type tapeOptFunc func(*Tape)
+
+func (f tapeOptFunc) applyTape(tape *Tape) {
+	f(tape)
+}
+
+// WithScrollback limits the number of lines of output retained for each
+// vertex. Once the limit is reached, the oldest lines are discarded.
+func WithScrollback(lines int) TapeOpt {
+	return tapeOptFunc(func(tape *Tape) {
+		tape.scrollback = lines
+	})
+}
+
+// WithLogExpiry evicts the logs of completed, non-failed vertexes once they
+// have neither been written to nor rendered for the given duration.
+func WithLogExpiry(expiry time.Duration) TapeOpt {
+	return tapeOptFunc(func(tape *Tape) {
+		tape.logExpiry = expiry
+	})
+}
+
+// WithLogLimit evicts the logs of completed, non-failed vertexes, least
+// recently used first, whenever the total size of logs held by the Tape
+// exceeds the given number of bytes.
+//
+// Logs of running and failed vertexes are never evicted, so the limit may be
+// exceeded.
+func WithLogLimit(bytes int) TapeOpt {
+	return tapeOptFunc(func(tape *Tape) {
+		tape.logLimit = bytes
+	})
+}
+
+// WithLogSpill writes evicted logs to a temporary file in dir, from which
+// they are paged back in when they are needed again, e.g. for the final
+// render. If dir is empty, the default directory for temporary files is used.
+//
+// The file is removed once the Tape is closed.
+func WithLogSpill(dir string) TapeOpt {
+	return tapeOptFunc(func(tape *Tape) {
+		tape.spill = &logSpill{
+			dir:   dir,
+			spans: make(map[string]*spilledLogs),
+		}
+	})
+}
+
+// spillCompactBytes is the number of bytes of stale logs that may accumulate
+// in the spill file before it is compacted.
+const spillCompactBytes = 256 << 10
+
+// logSpill is a temporary file containing logs evicted from the Tape.
+//
+// Logs that are spilled again after they changed leave their previous spans
+// behind, so the file is compacted once those make up most of it.
+type logSpill struct {
+	dir    string
+	file   *os.File
+	offset int64
+
+	// bytes of the file no longer referenced by any span
+	stale int64
+
+	spans map[string]*spilledLogs
+}
+
+// logUse records when a vertex's logs were last used.
+type logUse struct {
+	vertex string
+	used   time.Time
+}
+
+// spilledLogs records where a vertex's logs were written in the spill file.
+type spilledLogs struct {
+	// logs from previous attempts
+	attempts []spillSpan
+
+	// logs from the current attempt, if any
+	current *spillSpan
+
+	// size of the logs when they were spilled, to detect whether they need to
+	// be written again
+	size int
+
+	// whether the logs are currently held by the Tape
+	loaded bool
+}
+
+type spillSpan struct {
+	offset, length int64
+}
+
+func (spill *logSpill) create() (*os.File, error) {
+	file, err := os.CreateTemp(spill.dir, "progrock-logs-")
+	if err != nil {
+		return nil, err
+	}
+
+	// unlink the file right away so that it's cleaned up once it's closed or
+	// we exit; the open file remains usable (but this fails on Windows, so
+	// don't bother checking the error)
+	_ = os.Remove(file.Name())
+
+	return file, nil
+}
+
+func (spill *logSpill) write(data []byte) (spillSpan, error) {
+	if spill.file == nil {
+		file, err := spill.create()
+		if err != nil {
+			return spillSpan{}, err
+		}
+
+		spill.file = file
+	}
+
+	n, err := spill.file.WriteAt(data, spill.offset)
+	if err != nil {
+		return spillSpan{}, err
+	}
+
+	span := spillSpan{
+		offset: spill.offset,
+		length: int64(n),
+	}
+
+	spill.offset += int64(n)
+
+	return span, nil
+}
+
+func (spill *logSpill) read(span spillSpan) ([]byte, error) {
+	data := make([]byte, span.length)
+	_, err := spill.file.ReadAt(data, span.offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// release marks the spans of the spilled logs as stale.
+func (spill *logSpill) release(spilled *spilledLogs) {
+	for _, span := range spilled.attempts {
+		spill.stale += span.length
+	}
+
+	if spilled.current != nil {
+		spill.stale += spilled.current.length
+	}
+}
+
+// compact rewrites the spill file without its stale spans, once enough of
+// them have accumulated.
+func (spill *logSpill) compact() error {
+	if spill.stale < spillCompactBytes || spill.stale < spill.offset/2 {
+		return nil
+	}
+
+	file, err := spill.create()
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	move := func(span *spillSpan) error {
+		data, err := spill.read(*span)
+		if err != nil {
+			return err
+		}
+
+		if _, err := file.WriteAt(data, offset); err != nil {
+			return err
+		}
+
+		span.offset = offset
+		offset += span.length
+
+		return nil
+	}
+
+	for _, spilled := range spill.spans {
+		for i := range spilled.attempts {
+			if err := move(&spilled.attempts[i]); err != nil {
+				file.Close()
+				return err
+			}
+		}
+
+		if spilled.current != nil {
+			if err := move(spilled.current); err != nil {
+				file.Close()
+				return err
+			}
+		}
+	}
+
+	if err := spill.file.Close(); err != nil {
+		file.Close()
+		return err
+	}
+
+	spill.file = file
+	spill.offset = offset
+	spill.stale = 0
+
+	return nil
+}
+
+// close closes the spill file, which removes it.
+func (spill *logSpill) close() error {
+	if spill.file == nil {
+		return nil
+	}
+
+	return spill.file.Close()
+}
+
+// evict discards the logs of vertexes which are no longer worth keeping
+// around, according to the configured expiry and size limit.
+//
+// Vertexes are visited least recently used first, so that only those that
+// might be evicted are visited.
+func (tape *Tape) evict() error {
+	if tape.done {
+		// all output is shown once done
+		return nil
+	}
+
+	if tape.logExpiry > 0 {
+		now := now(tape.clock)
+
+		for e := tape.logsUsed.Front(); e != nil; {
+			use := e.Value.(*logUse)
+			next := e.Next()
+
+			if now.Sub(use.used) < tape.logExpiry {
+				// the rest were used more recently
+				break
+			}
+
+			vtx, found := tape.vertexes[use.vertex]
+			if found && evictable(vtx) && now.Sub(vtx.Completed.AsTime()) >= tape.logExpiry {
+				if err := tape.evictLogs(use.vertex); err != nil {
+					return err
+				}
+			}
+
+			e = next
+		}
+	}
+
+	if tape.logLimit > 0 {
+		for e := tape.logsUsed.Front(); e != nil && tape.totalLogBytes > tape.logLimit; {
+			use := e.Value.(*logUse)
+			next := e.Next()
+
+			vtx, found := tape.vertexes[use.vertex]
+			if found && evictable(vtx) {
+				if err := tape.evictLogs(use.vertex); err != nil {
+					return err
+				}
+			}
+
+			e = next
+		}
+	}
+
+	return nil
+}
+
+// evictable returns true if the vertex's logs may be evicted.
+func evictable(vtx *Vertex) bool {
+	return vtx.Completed != nil && vtx.Error == nil && !vtx.Canceled
+}
+
+// evictLogs discards the logs for the given vertex, spilling them to disk if
+// configured.
+func (tape *Tape) evictLogs(vertex string) error {
+	if tape.spill != nil {
+		spilled, found := tape.spill.spans[vertex]
+		if !found || spilled.size != tape.logBytes[vertex] {
+			if found {
+				tape.spill.release(spilled)
+			}
+
+			spilled = &spilledLogs{
+				size: tape.logBytes[vertex],
+			}
+
+			for _, term := range tape.attempts[vertex] {
+				span, err := tape.spill.write(term.Snapshot())
+				if err != nil {
+					return fmt.Errorf("spill logs: %w", err)
+				}
+
+				spilled.attempts = append(spilled.attempts, span)
+			}
+
+			if term, found := tape.logs[vertex]; found {
+				span, err := tape.spill.write(term.Snapshot())
+				if err != nil {
+					return fmt.Errorf("spill logs: %w", err)
+				}
+
+				spilled.current = &span
+			}
+
+			tape.spill.spans[vertex] = spilled
+
+			if err := tape.spill.compact(); err != nil {
+				return fmt.Errorf("compact spilled logs: %w", err)
+			}
+		}
+
+		spilled.loaded = false
+	}
+
+	tape.totalLogBytes -= tape.logBytes[vertex]
+	delete(tape.logBytes, vertex)
+	delete(tape.logs, vertex)
+	delete(tape.attempts, vertex)
+
+	if e, found := tape.logsUsedElems[vertex]; found {
+		tape.logsUsed.Remove(e)
+		delete(tape.logsUsedElems, vertex)
+	}
+
+	return nil
+}
+
+// useLogs pages in the vertex's logs if they were spilled and marks them as
+// recently used, delaying their eviction.
+func (tape *Tape) useLogs(vertex string) {
+	if err := tape.pageIn(vertex); err != nil {
+		tape.log(&Message{
+			Level:   MessageLevel_WARNING,
+			Message: err.Error(),
+			Labels: []*Label{
+				{Name: "vertex", Value: vertex},
+			},
+		})
+	}
+
+	now := now(tape.clock)
+
+	if e, found := tape.logsUsedElems[vertex]; found {
+		e.Value.(*logUse).used = now
+		tape.logsUsed.MoveToBack(e)
+	} else {
+		tape.logsUsedElems[vertex] = tape.logsUsed.PushBack(&logUse{
+			vertex: vertex,
+			used:   now,
+		})
+	}
+}
+
+// heldAttempts returns the number of previous attempts of the vertex whose
+// logs are held, whether in memory or spilled.
+func (tape *Tape) heldAttempts(vertex string) int {
+	if tape.spill != nil {
+		if spilled, found := tape.spill.spans[vertex]; found && !spilled.loaded {
+			return len(spilled.attempts)
+		}
+	}
+
+	return len(tape.attempts[vertex])
+}
+
+// pageInAll loads all spilled logs back into the Tape and closes the spill
+// file.
+func (tape *Tape) pageInAll() error {
+	if tape.spill == nil {
+		return nil
+	}
+
+	for vertex := range tape.spill.spans {
+		if err := tape.pageIn(vertex); err != nil {
+			return err
+		}
+	}
+
+	err := tape.spill.close()
+	tape.spill = nil
+	return err
+}
+
+// pageIn loads any spilled logs for the vertex back into the Tape.
+func (tape *Tape) pageIn(vertex string) error {
+	if tape.spill == nil {
+		return nil
+	}
+
+	spilled, found := tape.spill.spans[vertex]
+	if !found || spilled.loaded {
+		return nil
+	}
+
+	var attempts []*ui.Vterm
+	for _, span := range spilled.attempts {
+		term, err := tape.loadSpilled(span)
+		if err != nil {
+			return err
+		}
+
+		attempts = append(attempts, term)
+	}
+
+	if len(attempts) > 0 {
+		tape.attempts[vertex] = attempts
+	}
+
+	if spilled.current != nil {
+		term, err := tape.loadSpilled(*spilled.current)
+		if err != nil {
+			return err
+		}
+
+		tape.logs[vertex] = term
+	}
+
+	spilled.loaded = true
+
+	tape.logBytes[vertex] = spilled.size
+	tape.totalLogBytes += spilled.size
+
+	return nil
+}
+
+func (tape *Tape) loadSpilled(span spillSpan) (*ui.Vterm, error) {
+	data, err := tape.spill.read(span)
+	if err != nil {
+		return nil, fmt.Errorf("read spilled logs: %w", err)
+	}
+
+	term := tape.newVterm()
+	if _, err := term.Write(data); err != nil {
+		return nil, fmt.Errorf("write spilled logs: %w", err)
+	}
+
+	return term, nil
+}
@@ -2,20 +2,122 @@ package progrock
 
 import (
 	"context"
+	"crypto/subtle"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
 	"net"
 
 	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
+// ErrUnauthenticated is returned by an RPCWriter when the ProgressService
+// rejects its credentials.
+var ErrUnauthenticated = errors.New("progress service rejected credentials")
+
+// RPCOpt is an option for ServeRPC and DialRPC.
+type RPCOpt func(*rpcConfig)
+
+type rpcConfig struct {
+	tls           *tls.Config
+	token         string
+	insecureToken bool
+	serverOpts    []grpc.ServerOption
+	dialOpts      []grpc.DialOption
+}
+
+// validate checks that the token, if any, is protected by TLS.
+func (cfg rpcConfig) validate() error {
+	if cfg.token != "" && cfg.tls == nil && !cfg.insecureToken {
+		return errors.New("bearer token requires TLS; use WithTLS, or AllowInsecureToken for loopback connections")
+	}
+
+	return nil
+}
+
+// WithTLS secures the connection with TLS.
+//
+// When serving, the config must have a certificate. To require client
+// certificates for mTLS, set its ClientCAs and set ClientAuth to
+// tls.RequireAndVerifyClientCert.
+//
+// When dialing, the config's RootCAs verify the server's certificate, and its
+// Certificates are presented to servers that require a client certificate.
+func WithTLS(config *tls.Config) RPCOpt {
+	return func(cfg *rpcConfig) {
+		cfg.tls = config
+	}
+}
+
+// WithToken authenticates with a bearer token. When serving, streams without
+// the token are rejected as unauthenticated. When dialing, the token is sent
+// with each stream.
+//
+// The token must be used together with WithTLS, unless AllowInsecureToken is
+// given.
+func WithToken(token string) RPCOpt {
+	return func(cfg *rpcConfig) {
+		cfg.token = token
+	}
+}
+
+// AllowInsecureToken allows WithToken without WithTLS, sending the token in
+// plain text. It should only be used for loopback connections.
+func AllowInsecureToken() RPCOpt {
+	return func(cfg *rpcConfig) {
+		cfg.insecureToken = true
+	}
+}
+
+// WithServerOptions passes additional options to the gRPC server started by
+// ServeRPC.
+func WithServerOptions(opts ...grpc.ServerOption) RPCOpt {
+	return func(cfg *rpcConfig) {
+		cfg.serverOpts = append(cfg.serverOpts, opts...)
+	}
+}
+
+// WithDialOptions passes additional options to the gRPC client dialed by
+// DialRPC.
+func WithDialOptions(opts ...grpc.DialOption) RPCOpt {
+	return func(cfg *rpcConfig) {
+		cfg.dialOpts = append(cfg.dialOpts, opts...)
+	}
+}
+
 // ServeRPC serves a ProgressService over the given listener.
-func ServeRPC(l net.Listener, w Writer) (Writer, error) {
+func ServeRPC(l net.Listener, w Writer, opts ...RPCOpt) (Writer, error) {
+	var cfg rpcConfig
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	var srvOpts []grpc.ServerOption
+	if cfg.tls != nil {
+		srvOpts = append(srvOpts, grpc.Creds(credentials.NewTLS(cfg.tls)))
+	}
+
+	if cfg.token != "" {
+		// chained, so that it composes with interceptors in WithServerOptions
+		srvOpts = append(srvOpts, grpc.ChainStreamInterceptor(tokenInterceptor(cfg.token)))
+	}
+
+	srvOpts = append(srvOpts, cfg.serverOpts...)
+
 	recv := NewRPCReceiver(w)
 
-	srv := grpc.NewServer()
+	srv := grpc.NewServer(srvOpts...)
 	RegisterProgressServiceServer(srv, recv)
 
 	go srv.Serve(l)
@@ -27,8 +129,34 @@ func ServeRPC(l net.Listener, w Writer) (Writer, error) {
 }
 
 // DialRPC dials a ProgressService at the given target.
-func DialRPC(ctx context.Context, target string) (Writer, error) {
-	conn, err := grpc.DialContext(ctx, target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func DialRPC(ctx context.Context, target string, opts ...RPCOpt) (Writer, error) {
+	var cfg rpcConfig
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+
+	if cfg.tls != nil {
+		dialOpts[0] = grpc.WithTransportCredentials(credentials.NewTLS(cfg.tls))
+	}
+
+	if cfg.token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(tokenCredentials{
+			token:    cfg.token,
+			insecure: cfg.insecureToken,
+		}))
+	}
+
+	dialOpts = append(dialOpts, cfg.dialOpts...)
+
+	conn, err := grpc.DialContext(ctx, target, dialOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -37,12 +165,62 @@ func DialRPC(ctx context.Context, target string) (Writer, error) {
 
 	updates, err := client.WriteUpdates(ctx)
 	if err != nil {
-		return nil, err
+		return nil, rpcError(err)
 	}
 
 	return NewRPCWriter(conn, updates), nil
 }
 
+// tokenCredentials sends a bearer token with each RPC.
+type tokenCredentials struct {
+	token    string
+	insecure bool
+}
+
+func (creds tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		"authorization": "Bearer " + creds.token,
+	}, nil
+}
+
+// RequireTransportSecurity requires TLS unless AllowInsecureToken was given.
+func (creds tokenCredentials) RequireTransportSecurity() bool {
+	return !creds.insecure
+}
+
+// tokenInterceptor rejects streams that don't have the bearer token.
+func tokenInterceptor(token string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+
+		auth := md.Get("authorization")
+		if len(auth) == 0 {
+			return status.Error(codes.Unauthenticated, "missing bearer token")
+		}
+
+		given, found := cutPrefix(auth[0], "Bearer ")
+		if !found {
+			return status.Error(codes.Unauthenticated, "authorization is not a bearer token")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return status.Error(codes.Unauthenticated, "invalid bearer token")
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// rpcError wraps errors from a server that rejected the credentials with
+// ErrUnauthenticated.
+func rpcError(err error) error {
+	if status.Code(err) == codes.Unauthenticated {
+		return fmt.Errorf("%w: %s", ErrUnauthenticated, status.Convert(err).Message())
+	}
+
+	return err
+}
+
 // RPCWriter is a Writer that writes to a ProgressService.
 type RPCWriter struct {
 	Conn    *grpc.ClientConn
@@ -59,13 +237,22 @@ func NewRPCWriter(conn *grpc.ClientConn, updates ProgressService_WriteUpdatesCli
 
 // WriteStatus implements Writer.
 func (w *RPCWriter) WriteStatus(status *StatusUpdate) error {
-	return w.Updates.Send(status)
+	err := w.Updates.Send(status)
+	if errors.Is(err, io.EOF) {
+		// the stream was ended by the server; receive its error instead
+		_, err = w.Updates.CloseAndRecv()
+		if err == nil {
+			err = io.EOF
+		}
+	}
+
+	return rpcError(err)
 }
 
 // Close closes the underlying RPC connection.
 func (w *RPCWriter) Close() error {
 	_, err := w.Updates.CloseAndRecv()
-	return err
+	return rpcError(err)
 }
 
 // RPCReceiver is a ProgressServiceServer that writes to a Writer.
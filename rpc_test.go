@@ -0,0 +1,290 @@
+package progrock_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vito/progrock"
+	"google.golang.org/grpc"
+)
+
+func TestRPC(t *testing.T) {
+	ca := newTestCA(t)
+
+	serverCert := ca.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	clientCert := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+
+	serverTLS := &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+	}
+
+	mutualTLS := &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    ca.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+
+	clientTLS := &tls.Config{
+		RootCAs: ca.pool,
+	}
+
+	clientMutualTLS := &tls.Config{
+		RootCAs:      ca.pool,
+		Certificates: []tls.Certificate{clientCert},
+	}
+
+	for _, example := range []struct {
+		name   string
+		serve  []progrock.RPCOpt
+		dial   []progrock.RPCOpt
+		denied bool
+	}{
+		{
+			name: "insecure",
+		},
+		{
+			name:  "tls",
+			serve: []progrock.RPCOpt{progrock.WithTLS(serverTLS)},
+			dial:  []progrock.RPCOpt{progrock.WithTLS(clientTLS)},
+		},
+		{
+			name:  "token",
+			serve: []progrock.RPCOpt{progrock.WithTLS(serverTLS), progrock.WithToken("hunter2")},
+			dial:  []progrock.RPCOpt{progrock.WithTLS(clientTLS), progrock.WithToken("hunter2")},
+		},
+		{
+			name:   "wrong token",
+			serve:  []progrock.RPCOpt{progrock.WithTLS(serverTLS), progrock.WithToken("hunter2")},
+			dial:   []progrock.RPCOpt{progrock.WithTLS(clientTLS), progrock.WithToken("*******")},
+			denied: true,
+		},
+		{
+			name:   "missing token",
+			serve:  []progrock.RPCOpt{progrock.WithTLS(serverTLS), progrock.WithToken("hunter2")},
+			dial:   []progrock.RPCOpt{progrock.WithTLS(clientTLS)},
+			denied: true,
+		},
+		{
+			name:  "insecure token",
+			serve: []progrock.RPCOpt{progrock.WithToken("hunter2"), progrock.AllowInsecureToken()},
+			dial:  []progrock.RPCOpt{progrock.WithToken("hunter2"), progrock.AllowInsecureToken()},
+		},
+		{
+			name:  "mtls",
+			serve: []progrock.RPCOpt{progrock.WithTLS(mutualTLS)},
+			dial:  []progrock.RPCOpt{progrock.WithTLS(clientMutualTLS)},
+		},
+	} {
+		example := example
+		t.Run(example.name, func(t *testing.T) {
+			updates := &updateLog{}
+			addr := serve(t, updates, example.serve...)
+
+			err := send(addr, example.dial...)
+			if example.denied {
+				require.ErrorIs(t, err, progrock.ErrUnauthenticated)
+				require.Empty(t, updates.vertexes())
+			} else {
+				require.NoError(t, err)
+				require.NotEmpty(t, updates.vertexes())
+			}
+		})
+	}
+
+	t.Run("token without tls", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer l.Close()
+
+		_, err = progrock.ServeRPC(l, &updateLog{}, progrock.WithToken("hunter2"))
+		require.ErrorContains(t, err, "requires TLS")
+
+		_, err = progrock.DialRPC(context.Background(), l.Addr().String(), progrock.WithToken("hunter2"))
+		require.ErrorContains(t, err, "requires TLS")
+	})
+
+	t.Run("token with other interceptors", func(t *testing.T) {
+		var intercepted int32
+		interceptor := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			atomic.AddInt32(&intercepted, 1)
+			return handler(srv, ss)
+		}
+
+		updates := &updateLog{}
+		addr := serve(t, updates,
+			progrock.WithTLS(serverTLS),
+			progrock.WithToken("hunter2"),
+			progrock.WithServerOptions(grpc.StreamInterceptor(interceptor)))
+
+		require.NoError(t, send(addr, progrock.WithTLS(clientTLS), progrock.WithToken("hunter2")))
+		require.NotEmpty(t, updates.vertexes())
+		require.Equal(t, int32(1), atomic.LoadInt32(&intercepted))
+
+		require.ErrorIs(t, send(addr, progrock.WithTLS(clientTLS)), progrock.ErrUnauthenticated)
+	})
+
+	t.Run("tls without client tls", func(t *testing.T) {
+		updates := &updateLog{}
+		addr := serve(t, updates, progrock.WithTLS(serverTLS))
+
+		err := send(addr)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, progrock.ErrUnauthenticated)
+		require.Empty(t, updates.vertexes())
+	})
+
+	t.Run("mtls without client certificate", func(t *testing.T) {
+		updates := &updateLog{}
+		addr := serve(t, updates, progrock.WithTLS(mutualTLS))
+
+		err := send(addr, progrock.WithTLS(clientTLS))
+		require.Error(t, err)
+		require.Empty(t, updates.vertexes())
+	})
+}
+
+// serve serves a ProgressService on a local port, returning its address.
+func serve(t *testing.T, w progrock.Writer, opts ...progrock.RPCOpt) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv, err := progrock.ServeRPC(l, w, opts...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		srv.Close()
+	})
+
+	return l.Addr().String()
+}
+
+// send records a vertex to the ProgressService at addr, returning the first
+// error.
+func send(addr string, opts ...progrock.RPCOpt) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	w, err := progrock.DialRPC(ctx, addr, opts...)
+	if err != nil {
+		return err
+	}
+
+	writeErr := w.WriteStatus(&progrock.StatusUpdate{
+		Vertexes: []*progrock.Vertex{{Id: "vertex", Name: "vertex"}},
+	})
+
+	closeErr := w.Close()
+	if writeErr != nil {
+		return writeErr
+	}
+
+	return closeErr
+}
+
+// updateLog is a Writer that keeps the updates written to it.
+type updateLog struct {
+	updates []*progrock.StatusUpdate
+	l       sync.Mutex
+}
+
+func (log *updateLog) WriteStatus(status *progrock.StatusUpdate) error {
+	log.l.Lock()
+	defer log.l.Unlock()
+	log.updates = append(log.updates, status)
+	return nil
+}
+
+func (log *updateLog) Close() error {
+	return nil
+}
+
+func (log *updateLog) vertexes() []*progrock.Vertex {
+	log.l.Lock()
+	defer log.l.Unlock()
+
+	var vertexes []*progrock.Vertex
+	for _, update := range log.updates {
+		vertexes = append(vertexes, update.Vertexes...)
+	}
+
+	return vertexes
+}
+
+// testCA is a self-signed certificate authority for issuing test
+// certificates.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+
+	// serial number of the last certificate issued
+	serial int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "progrock test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &testCA{
+		cert:   cert,
+		key:    key,
+		pool:   pool,
+		serial: 1,
+	}
+}
+
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ca.serial++
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+}
@@ -0,0 +1,204 @@
+package progrock
+
+import (
+	"bytes"
+	"io"
+)
+
+// tapeItem is a vertex or group rendered by the Tape, which may be selected.
+type tapeItem struct {
+	// ID of the vertex, if the item is a vertex
+	vertex string
+	// ID of the group, if the item is a group
+	group string
+	// line on which the item was rendered
+	line int
+}
+
+func (item tapeItem) is(other tapeItem) bool {
+	return item.vertex == other.vertex && item.group == other.group
+}
+
+// SelectPrevious moves the selection to the previously rendered vertex or
+// group, selecting the last one if nothing is selected.
+func (tape *Tape) SelectPrevious() {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+
+	idx, found := tape.selectedIndex()
+	if !found {
+		tape.selectIndex(len(tape.items) - 1)
+		return
+	}
+
+	tape.selectIndex(idx - 1)
+}
+
+// SelectNext moves the selection to the next rendered vertex or group,
+// selecting the first one if nothing is selected.
+func (tape *Tape) SelectNext() {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+
+	idx, found := tape.selectedIndex()
+	if !found {
+		tape.selectIndex(0)
+		return
+	}
+
+	tape.selectIndex(idx + 1)
+}
+
+// SelectFirst selects the first rendered vertex or group.
+func (tape *Tape) SelectFirst() {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+	tape.selectIndex(0)
+}
+
+// SelectLast selects the last rendered vertex or group.
+func (tape *Tape) SelectLast() {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+	tape.selectIndex(len(tape.items) - 1)
+}
+
+// ClearSelection deselects the selected vertex or group.
+func (tape *Tape) ClearSelection() {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+	tape.selected = tapeItem{}
+}
+
+// SelectedLine returns the line on which the selected vertex or group was
+// last rendered, or false if nothing is selected.
+func (tape *Tape) SelectedLine() (int, bool) {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+
+	idx, found := tape.selectedIndex()
+	if !found {
+		return 0, false
+	}
+
+	return tape.items[idx].line, true
+}
+
+// ToggleSelected expands or collapses the logs and tasks of the selected
+// vertex, overriding ShowAllOutput, or the selected group, overriding
+// AutoCollapse.
+func (tape *Tape) ToggleSelected() {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+
+	if group, found := tape.groups[tape.selected.group]; found {
+		summary := tape.groupSummaries()[group.Id]
+		tape.groupsCollapsed[group.Id] = !tape.groupCollapsed(group, summary)
+		return
+	}
+
+	vtx, found := tape.vertexes[tape.selected.vertex]
+	if !found {
+		return
+	}
+
+	tape.expanded[vtx.Id] = !tape.showOutput(vtx)
+}
+
+// ToggleSelectedAttempts shows or hides the output of previous attempts of
+// the selected vertex, overriding ShowAttempts.
+func (tape *Tape) ToggleSelectedAttempts() {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+
+	vtx, found := tape.vertexes[tape.selected.vertex]
+	if !found {
+		return
+	}
+
+	tape.attemptsToggled[vtx.Id] = !tape.attemptsShown(vtx)
+}
+
+// SelectedAttemptsShown returns whether the output of previous attempts of
+// the selected vertex is shown, or false if no vertex is selected.
+func (tape *Tape) SelectedAttemptsShown() bool {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+
+	vtx, found := tape.vertexes[tape.selected.vertex]
+	if !found {
+		return false
+	}
+
+	return tape.attemptsShown(vtx)
+}
+
+func (tape *Tape) selectedIndex() (int, bool) {
+	for i, item := range tape.items {
+		if item.is(tape.selected) {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+func (tape *Tape) selectIndex(idx int) {
+	if len(tape.items) == 0 {
+		return
+	}
+
+	if idx < 0 {
+		idx = 0
+	} else if idx >= len(tape.items) {
+		idx = len(tape.items) - 1
+	}
+
+	tape.selected = tape.items[idx]
+}
+
+// showOutput returns whether to show the logs of the vertex.
+func (tape *Tape) showOutput(vtx *Vertex) bool {
+	if tape.done {
+		return true
+	}
+
+	if expanded, found := tape.expanded[vtx.Id]; found {
+		return expanded
+	}
+
+	return tape.showAllOutput || vtx.Completed == nil || vtx.Error != nil
+}
+
+// attemptsShown returns whether to show the output of the vertex's previous
+// attempts.
+func (tape *Tape) attemptsShown(vtx *Vertex) bool {
+	if shown, found := tape.attemptsToggled[vtx.Id]; found {
+		return shown
+	}
+
+	return tape.showAttempts
+}
+
+// collapsed returns whether the vertex's tasks and logs have been hidden by
+// the user.
+func (tape *Tape) collapsed(vtx *Vertex) bool {
+	if tape.done {
+		return false
+	}
+
+	expanded, found := tape.expanded[vtx.Id]
+	return found && !expanded
+}
+
+// lineWriter counts the lines written to the underlying writer.
+type lineWriter struct {
+	io.Writer
+	lines int
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.lines += bytes.Count(p[:n], []byte{'\n'})
+	return n, err
+}
@@ -2,11 +2,14 @@ package progrock
 
 import (
 	"bytes"
+	"container/list"
 	"fmt"
 	"io"
 	"sort"
 	"sync"
+	"time"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/muesli/termenv"
 	"github.com/vito/progrock/ui"
 )
@@ -26,8 +29,9 @@ type Tape struct {
 	vertex2groups  map[string]map[string]struct{}
 
 	// vertex state
-	tasks map[string][]*VertexTask
-	logs  map[string]*ui.Vterm
+	tasks    map[string][]*VertexTask
+	logs     map[string]*ui.Vterm
+	attempts map[string][]*ui.Vterm // logs for previous attempts
 
 	// whether the tape has been closed
 	done bool
@@ -42,57 +46,84 @@ type Tape struct {
 	verboseEdges  bool // show edges between vertexes in the same group
 	showInternal  bool // show internal vertexes
 	showAllOutput bool // show output even for completed vertexes
+	showAttempts  bool // show output of previous attempts for every vertex
 	focus         bool // only show 'focused' vertex output, condensing the rest
 
-	// output from messages and internal debugging
-	globalLogs *ui.Vterm
+	// only show vertexes matching a query entered by the user
+	filter *vertexFilter
+
+	// recent messages, which are rendered below the vertexes, minus those that
+	// can't be shown at any message level
+	messages []*Message
+
+	// vertexes and groups in the order they were last rendered
+	items []tapeItem
+
+	// the vertex or group selected by the user
+	selected tapeItem
+
+	// vertexes whose output has been expanded or collapsed by the user
+	expanded map[string]bool
+
+	// vertexes whose previous attempts have been shown or hidden by the user
+	attemptsToggled map[string]bool
+
+	// groups which have been collapsed or expanded by the user
+	groupsCollapsed map[string]bool
+
+	// collapse groups once all of their vertexes have succeeded
+	autoCollapse bool
+
+	// copies of vertex logs being displayed by a Pager
+	mirrors map[string]*ui.Vterm
 
 	// minimum message level to display to the user
 	messageLevel MessageLevel
 
+	// clock used for measuring the duration of running vertexes and tasks
+	clock clockwork.Clock
+
+	// expected durations of vertexes, and the smoothed progress estimate
+	expectedDuration ExpectedDurationFunc
+	estimate         estimateState
+
+	// log retention config
+	scrollback int           // max lines of output per vertex
+	logExpiry  time.Duration // evict unused logs of completed vertexes after
+	logLimit   int           // evict logs of completed vertexes beyond total size
+	spill      *logSpill     // where to put evicted logs, if anywhere
+
+	// log retention state
+	logBytes      map[string]int           // bytes of logs held for each vertex
+	logsUsed      *list.List               // *logUse, least recently used first
+	logsUsedElems map[string]*list.Element // logsUsed elements by vertex
+	totalLogBytes int
+
 	l sync.Mutex
 }
 
-const (
-	block       = "█"
-	dot         = "●"
-	emptyDot    = "○"
-	vBar        = "│"
-	vbBar       = "┃"
-	hBar        = "─"
-	hdlBar      = "╴"
-	hdrBar      = "╶"
-	tBar        = "┼"
-	dBar        = "┊" // ┊┆┇┋╎
-	blCorner    = "╰"
-	tlCorner    = "╭"
-	trCorner    = "╮"
-	brCorner    = "╯"
-	vlBar       = "┤"
-	vrBar       = "├"
-	vlbBar      = "┫"
-	vrbBar      = "┣"
-	htBar       = "┴"
-	htbBar      = "┻"
-	hbBar       = "┬"
-	lCaret      = "◀" //"<"
-	rCaret      = "▶" //">"
-	dCaret      = "▼"
-	dEmptyCaret = "▽"
-
-	taskSymbol          = vrbBar
-	inactiveGroupSymbol = vBar
-)
+// TapeOpt is an option for creating a Tape.
+type TapeOpt interface {
+	applyTape(*Tape)
+}
 
 // NewTape returns a new Tape.
-func NewTape() *Tape {
-	return &Tape{
-		vertexes:       make(map[string]*Vertex),
-		groups:         make(map[string]*Group),
-		group2vertexes: make(map[string]map[string]struct{}),
-		vertex2groups:  make(map[string]map[string]struct{}),
-		tasks:          make(map[string][]*VertexTask),
-		logs:           make(map[string]*ui.Vterm),
+func NewTape(opts ...TapeOpt) *Tape {
+	tape := &Tape{
+		vertexes:        make(map[string]*Vertex),
+		groups:          make(map[string]*Group),
+		group2vertexes:  make(map[string]map[string]struct{}),
+		vertex2groups:   make(map[string]map[string]struct{}),
+		tasks:           make(map[string][]*VertexTask),
+		logs:            make(map[string]*ui.Vterm),
+		attempts:        make(map[string][]*ui.Vterm),
+		logBytes:        make(map[string]int),
+		logsUsed:        list.New(),
+		logsUsedElems:   make(map[string]*list.Element),
+		expanded:        make(map[string]bool),
+		attemptsToggled: make(map[string]bool),
+		groupsCollapsed: make(map[string]bool),
+		mirrors:         make(map[string]*ui.Vterm),
 
 		// for explicitness: default to unbounded screen size
 		width:  -1,
@@ -101,10 +132,14 @@ func NewTape() *Tape {
 		// sane default before window size is known
 		termHeight: 10,
 
-		globalLogs: ui.NewVterm(),
-
 		messageLevel: MessageLevel_WARNING,
 	}
+
+	for _, o := range opts {
+		o.applyTape(tape)
+	}
+
+	return tape
 }
 
 var _ Writer = &Tape{}
@@ -126,9 +161,23 @@ func (tape *Tape) WriteStatus(status *StatusUpdate) error {
 		} else if existing.Completed != nil && v.Cached {
 			// don't clobber the "real" vertex with a cache
 			// TODO: count cache hits?
+			continue
 		} else {
 			tape.vertexes[v.Id] = v
 		}
+
+		if len(v.Attempts) > tape.heldAttempts(v.Id) {
+			// the logs are about to be set aside, so they need to be held
+			if err := tape.pageIn(v.Id); err != nil {
+				return err
+			}
+		}
+
+		// set aside logs from previous attempts
+		for len(tape.attempts[v.Id]) < len(v.Attempts) {
+			tape.attempts[v.Id] = append(tape.attempts[v.Id], tape.vertexLogs(v.Id))
+			delete(tape.logs, v.Id)
+		}
 	}
 
 	for _, t := range status.Tasks {
@@ -147,11 +196,20 @@ func (tape *Tape) WriteStatus(status *StatusUpdate) error {
 	}
 
 	for _, l := range status.Logs {
-		sink := tape.vertexLogs(l.Vertex)
+		sink := tape.attemptLogs(l.Vertex, int(l.Attempt))
 		_, err := sink.Write(l.Data)
 		if err != nil {
 			return fmt.Errorf("write logs: %w", err)
 		}
+
+		tape.logBytes[l.Vertex] += len(l.Data)
+		tape.totalLogBytes += len(l.Data)
+
+		if mirror, found := tape.mirrors[l.Vertex]; found && sink == tape.logs[l.Vertex] {
+			if _, err := mirror.Write(l.Data); err != nil {
+				return fmt.Errorf("write logs to pager: %w", err)
+			}
+		}
 	}
 
 	for _, ms := range status.Memberships {
@@ -180,33 +238,92 @@ func (tape *Tape) WriteStatus(status *StatusUpdate) error {
 		tape.log(msg)
 	}
 
-	return nil
+	return tape.evict()
 }
 
+// log records the message, forgetting older messages that can no longer be
+// shown.
 func (tape *Tape) log(msg *Message) {
-	if msg.Level < tape.messageLevel {
+	tape.messages = append(tape.messages, msg)
+
+	// a message can't be shown at any message level once there are
+	// messageLines newer messages at or above its level, since they'd be shown
+	// in its place
+	newer := map[MessageLevel]int{}
+	kept := make([]*Message, 0, len(tape.messages))
+	for i := len(tape.messages) - 1; i >= 0; i-- {
+		msg := tape.messages[i]
+
+		var outranked int
+		for level, n := range newer {
+			if level >= msg.Level {
+				outranked += n
+			}
+		}
+
+		if outranked < messageLines {
+			kept = append(kept, msg)
+		}
+
+		newer[msg.Level]++
+	}
+
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+
+	tape.messages = kept
+}
+
+// messageLines is the number of lines of messages shown below the vertexes.
+const messageLines = 10
+
+// renderMessages renders the most recent messages at or above the message
+// level, colored with the theme.
+func (tape *Tape) renderMessages(w io.Writer, theme ui.Theme) {
+	// each message takes at least a line, so only the last few can be shown
+	var shown []*Message
+	for i := len(tape.messages) - 1; i >= 0 && len(shown) < messageLines; i-- {
+		if msg := tape.messages[i]; msg.Level >= tape.messageLevel {
+			shown = append(shown, msg)
+		}
+	}
+
+	if len(shown) == 0 {
 		return
 	}
 
+	term := ui.NewVterm()
+	if tape.width != -1 {
+		term.SetWidth(tape.width)
+	}
+
+	for i := len(shown) - 1; i >= 0; i-- {
+		printMessage(term, theme, shown[i])
+	}
+
+	term.SetHeight(messageLines)
+	fmt.Fprint(w, term.View())
+}
+
+func printMessage(w io.Writer, theme ui.Theme, msg *Message) {
 	var prefix termenv.Style
 	switch msg.Level {
 	case MessageLevel_DEBUG:
-		prefix = termenv.String("DEBUG:").Foreground(termenv.ANSIBlue).Bold()
+		prefix = theme.Profile.String("DEBUG:").Foreground(theme.Profile.Color(theme.Debug)).Bold()
 	case MessageLevel_WARNING:
-		prefix = termenv.String("WARNING:").Foreground(termenv.ANSIYellow).Bold()
+		prefix = theme.Profile.String("WARNING:").Foreground(theme.Profile.Color(theme.Warning)).Bold()
 	case MessageLevel_ERROR:
-		prefix = termenv.String("ERROR:").Foreground(termenv.ANSIRed).Bold()
+		prefix = theme.Profile.String("ERROR:").Foreground(theme.Profile.Color(theme.Error)).Bold()
 	}
 
 	out := msg.Message
 	for _, l := range msg.Labels {
 		out += " "
-		out += termenv.String(fmt.Sprintf("%s=%q", l.Name, l.Value)).
-			Foreground(termenv.ANSIBrightBlack).
-			String()
+		out += theme.Color(theme.Muted, fmt.Sprintf("%s=%q", l.Name, l.Value))
 	}
 
-	fmt.Fprintln(tape.globalLogs, prefix, out)
+	fmt.Fprintln(w, prefix, out)
 }
 
 func (tape *Tape) Vertices() []*Vertex {
@@ -298,12 +415,13 @@ func (tape *Tape) TotalCount() int {
 }
 
 // Close marks the Tape as done, which tells it to display all vertex output
-// for the final render.
+// for the final render. Any spilled logs are paged back in for it, and the
+// spill file is closed.
 func (tape *Tape) Close() error {
 	tape.l.Lock()
+	defer tape.l.Unlock()
 	tape.done = true
-	tape.l.Unlock()
-	return nil
+	return tape.pageInAll()
 }
 
 // VerboseEdges sets whether to display edges between vertexes in the same
@@ -328,6 +446,24 @@ func (tape *Tape) ShowAllOutput(show bool) {
 	tape.showAllOutput = show
 }
 
+// ShowAttempts sets whether to show the output of previous attempts for
+// vertexes that were retried. It can be overridden for the selected vertex
+// with ToggleSelectedAttempts.
+func (tape *Tape) ShowAttempts(show bool) {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+	tape.showAttempts = show
+}
+
+// AutoCollapse sets whether to collapse groups into a single line once all
+// of their vertexes have completed successfully. Groups expanded or
+// collapsed by the user are left alone.
+func (tape *Tape) AutoCollapse(collapse bool) {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+	tape.autoCollapse = collapse
+}
+
 // Focus sets whether to hide output of non-focused vertexes.
 func (tape *Tape) Focus(focused bool) {
 	tape.l.Lock()
@@ -335,13 +471,37 @@ func (tape *Tape) Focus(focused bool) {
 	tape.focus = focused
 }
 
-// MessageLevel sets the minimum level for messages to display.
+// MessageLevel sets the minimum level for messages to display, including
+// messages that have already been received.
 func (tape *Tape) MessageLevel(level MessageLevel) {
 	tape.l.Lock()
 	defer tape.l.Unlock()
+
 	tape.messageLevel = level
 }
 
+// DisplaySettings are the settings that control what the Tape renders.
+type DisplaySettings struct {
+	VerboseEdges  bool
+	ShowInternal  bool
+	ShowAllOutput bool
+	Focus         bool
+	MessageLevel  MessageLevel
+}
+
+// DisplaySettings returns the current display settings.
+func (tape *Tape) DisplaySettings() DisplaySettings {
+	tape.l.Lock()
+	defer tape.l.Unlock()
+	return DisplaySettings{
+		VerboseEdges:  tape.verboseEdges,
+		ShowInternal:  tape.showInternal,
+		ShowAllOutput: tape.showAllOutput,
+		Focus:         tape.focus,
+		MessageLevel:  tape.messageLevel,
+	}
+}
+
 // SetWindowSize sets the size of the terminal UI, which influences the
 // dimensions for vertex logs, progress bars, etc.
 func (tape *Tape) SetWindowSize(w, h int) {
@@ -352,34 +512,68 @@ func (tape *Tape) SetWindowSize(w, h int) {
 	for _, l := range tape.logs {
 		l.SetWidth(w)
 	}
-	tape.globalLogs.SetWidth(w)
+	for _, ls := range tape.attempts {
+		for _, l := range ls {
+			l.SetWidth(w)
+		}
+	}
+	for _, l := range tape.mirrors {
+		l.SetWidth(w)
+	}
 	tape.l.Unlock()
 }
 
-var pulse ui.Frames
-
-func init() {
-	pulse = ui.Frames{}
-	copy(pulse[:], ui.FadeFrames[:])
+// pulseFrames returns the fade frames, but holding on the frame three
+// quarters of the way through rather than fading out completely.
+func pulseFrames(fade ui.Frames) ui.Frames {
+	pulse := fade
 	lastFrame := (ui.FramesPerBeat / 4) * 3
 	for i := lastFrame; i < len(pulse); i++ {
-		pulse[i] = ui.FadeFrames[lastFrame]
+		pulse[i] = fade[lastFrame]
 	}
+	return pulse
 }
 
 func (tape *Tape) Render(w io.Writer, u *UI) error {
 	tape.l.Lock()
 	defer tape.l.Unlock()
 
+	if err := tape.evict(); err != nil {
+		return err
+	}
+
+	now := now(tape.clock)
+
+	tape.advanceEstimate(now)
+
+	lw := &lineWriter{Writer: w}
+	w = lw
+
+	tape.items = nil
+
+	summaries := tape.groupSummaries()
+
 	b := &bouncer{
 		groups:         tape.groups,
 		group2vertexes: tape.group2vertexes,
 		vertex2groups:  tape.vertex2groups,
+		summaries:      summaries,
+		collapsed:      tape.collapsedGroups(summaries),
+		selected:       tape.selected.group,
 
 		focus:        tape.focus,
 		showInternal: tape.showInternal,
 	}
 
+	if !tape.focus {
+		b.rendered = func(group *Group) {
+			tape.items = append(tape.items, tapeItem{
+				group: group.Id,
+				line:  lw.lines,
+			})
+		}
+	}
+
 	var groupsW io.Writer
 	if tape.focus {
 		groupsW = io.Discard
@@ -393,6 +587,12 @@ func (tape *Tape) Render(w io.Writer, u *UI) error {
 	for _, dig := range tape.order {
 		vtx := tape.vertexes[dig]
 
+		if !tape.matchesFilter(vtx) {
+			// drop vertexes excluded by the filter entirely, so that their groups
+			// and edges aren't shown either
+			continue
+		}
+
 		if vtx.Completed == nil || vtx.Error != nil {
 			runningAndFailed = append(runningAndFailed, vtx)
 		} else {
@@ -401,6 +601,30 @@ func (tape *Tape) Render(w io.Writer, u *UI) error {
 	}
 
 	order := append(completed, runningAndFailed...)
+
+	// collapsed groups are rendered in place of their first vertex, and the
+	// rest of their vertexes are skipped
+	representing := map[string]*Group{}
+	if len(b.collapsed) > 0 {
+		represented := map[string]bool{}
+		visible := make([]*Vertex, 0, len(order))
+		for _, vtx := range order {
+			group, collapsed := b.CollapsedGroup(vtx)
+			if collapsed {
+				if represented[group.Id] {
+					continue
+				}
+
+				represented[group.Id] = true
+				representing[vtx.Id] = group
+			}
+
+			visible = append(visible, vtx)
+		}
+
+		order = visible
+	}
+
 	groups := progressGroups{}
 
 	for i, vtx := range order {
@@ -412,6 +636,11 @@ func (tape *Tape) Render(w io.Writer, u *UI) error {
 
 		groups = groups.Reap(groupsW, u, order[i:])
 
+		if group, found := representing[vtx.Id]; found {
+			groups = groups.AddGroup(groupsW, u, b, group, tape.log)
+			continue
+		}
+
 		for _, g := range b.Groups(vtx) {
 			groups = groups.AddGroup(groupsW, u, b, g, tape.log)
 		}
@@ -420,24 +649,55 @@ func (tape *Tape) Render(w io.Writer, u *UI) error {
 			continue
 		}
 
-		symbol := block
+		symbol := u.theme.Glyphs.Block
 		if vtx.Completed == nil {
-			symbol, _, _ = u.Spinner.ViewFrame(pulse)
+			symbol, _, _ = u.Spinner.ViewFrame(pulseFrames(u.theme.Glyphs.Fade))
 		}
 
+		tape.items = append(tape.items, tapeItem{
+			vertex: vtx.Id,
+			line:   lw.lines,
+		})
+
 		groups.VertexPrefix(groupsW, u, vtx, symbol, tape.log)
-		if err := u.RenderVertex(w, vtx); err != nil {
+		if err := u.renderVertex(w, vtx, now, tape.selected.vertex == vtx.Id, tape.expected(vtx)); err != nil {
 			return err
 		}
 
-		tasks := tape.tasks[vtx.Id]
+		var tasks []*VertexTask
+		if !tape.collapsed(vtx) {
+			tasks = tape.tasks[vtx.Id]
+		}
+
 		for _, t := range tasks {
 			groups.TaskPrefix(groupsW, u, vtx)
-			if err := u.RenderTask(w, t); err != nil {
+			if err := u.renderTask(w, t, now); err != nil {
 				return err
 			}
 		}
 
+		if tape.attemptsShown(vtx) && !tape.collapsed(vtx) {
+			for i, attempt := range vtx.Attempts {
+				groups.TaskPrefix(groupsW, u, vtx)
+				if err := u.renderAttempt(w, i+1, attempt, now); err != nil {
+					return err
+				}
+
+				term := tape.attemptLogs(vtx.Id, i)
+				term.SetHeight(term.UsedHeight())
+
+				if !tape.focus {
+					buf := new(bytes.Buffer)
+					groups.TermPrefix(buf, u, vtx)
+					term.SetPrefix(buf.String())
+				}
+
+				if err := u.RenderTerm(w, term); err != nil {
+					return err
+				}
+			}
+		}
+
 		activeExceptCurrent := order[1:]
 
 		var haveInput []*Vertex
@@ -460,10 +720,10 @@ func (tape *Tape) Render(w io.Writer, u *UI) error {
 			groups = groups.AddVertex(groupsW, u, tape.groups, vtx, haveInput)
 		}
 
-		if tape.done || tape.showAllOutput || vtx.Completed == nil || vtx.Error != nil {
+		if tape.showOutput(vtx) {
 			term := tape.vertexLogs(vtx.Id)
 
-			if vtx.Error != nil {
+			if vtx.Error != nil || (vtx.Completed != nil && tape.expanded[vtx.Id]) {
 				term.SetHeight(term.UsedHeight())
 			} else {
 				term.SetHeight(tape.termHeight)
@@ -487,13 +747,17 @@ func (tape *Tape) Render(w io.Writer, u *UI) error {
 
 	groups.Reap(groupsW, u, nil)
 
-	tape.globalLogs.SetHeight(10)
-	fmt.Fprint(w, tape.globalLogs.View())
+	tape.renderMessages(w, u.theme)
 
 	return nil
 }
 
 func (tape *Tape) filteredOut(vtx *Vertex) bool {
+	if !tape.matchesFilter(vtx) {
+		// filter out vertices that don't match the user's query
+		return true
+	}
+
 	if vtx.Internal && !tape.showInternal {
 		// filter out internal vertices unless we're showing them
 		return true
@@ -548,28 +812,160 @@ func (tape *Tape) insert(id string, vtx *Vertex) {
 }
 
 func (tape *Tape) vertexLogs(vertex string) *ui.Vterm {
+	tape.useLogs(vertex)
+
 	term, found := tape.logs[vertex]
 	if !found {
-		term = ui.NewVterm()
-		if tape.width != -1 {
-			term.SetWidth(tape.width)
-		}
+		term = tape.newVterm()
 		tape.logs[vertex] = term
 	}
 
 	return term
 }
 
+func (tape *Tape) newVterm() *ui.Vterm {
+	term := ui.NewVterm()
+	if tape.width != -1 {
+		term.SetWidth(tape.width)
+	}
+	if tape.scrollback > 0 {
+		term.SetMaxLines(tape.scrollback)
+	}
+	return term
+}
+
+// groupSummaries returns a rollup of vertex state for each group, including
+// the vertexes of its sub-groups.
+func (tape *Tape) groupSummaries() map[string]*GroupSummary {
+	summaries := map[string]*GroupSummary{}
+	for vid, vtx := range tape.vertexes {
+		if vtx.Internal && !tape.showInternal {
+			continue
+		}
+
+		if !tape.matchesFilter(vtx) {
+			continue
+		}
+
+		for gid := range tape.vertex2groups[vid] {
+			for {
+				summary, found := summaries[gid]
+				if !found {
+					summary = &GroupSummary{}
+					summaries[gid] = summary
+				}
+
+				summary.Add(vtx)
+
+				group, found := tape.groups[gid]
+				if !found || group.Parent == nil {
+					break
+				}
+
+				gid = group.GetParent()
+			}
+		}
+	}
+
+	return summaries
+}
+
+// collapsedGroups returns the set of groups to render as a single line.
+func (tape *Tape) collapsedGroups(summaries map[string]*GroupSummary) map[string]bool {
+	collapsed := map[string]bool{}
+	for id, group := range tape.groups {
+		if tape.groupCollapsed(group, summaries[id]) {
+			collapsed[id] = true
+		}
+	}
+
+	return collapsed
+}
+
+// groupCollapsed returns whether the group should be rendered as a single
+// line, either because the user collapsed it or because it succeeded and
+// AutoCollapse is enabled.
+func (tape *Tape) groupCollapsed(group *Group, summary *GroupSummary) bool {
+	if tape.done {
+		return false
+	}
+
+	if collapsed, found := tape.groupsCollapsed[group.Id]; found {
+		return collapsed
+	}
+
+	if !tape.autoCollapse || summary == nil {
+		return false
+	}
+
+	if group.Name == RootGroup && group.Parent == nil {
+		// the root group isn't rendered as a line, so it can't be collapsed
+		return false
+	}
+
+	return group.Error == nil &&
+		!group.Canceled &&
+		summary.Total > 0 &&
+		summary.Completed == summary.Total
+}
+
+// attemptLogs returns the logs for the given attempt of the vertex, which may
+// be the current attempt.
+func (tape *Tape) attemptLogs(vertex string, attempt int) *ui.Vterm {
+	tape.useLogs(vertex)
+
+	previous := tape.attempts[vertex]
+	if attempt < len(previous) {
+		return previous[attempt]
+	}
+
+	return tape.vertexLogs(vertex)
+}
+
 type bouncer struct {
 	groups         map[string]*Group
 	vertices       map[string]*Vertex
 	group2vertexes map[string]map[string]struct{}
 	vertex2groups  map[string]map[string]struct{}
+	summaries      map[string]*GroupSummary
+
+	// IDs of groups to render as a single line
+	collapsed map[string]bool
+
+	// ID of the selected group
+	selected string
+
+	// called when a group is rendered
+	rendered func(*Group)
 
 	focus        bool
 	showInternal bool
 }
 
+func (b *bouncer) Summary(group *Group) GroupSummary {
+	summary, found := b.summaries[group.Id]
+	if !found {
+		return GroupSummary{}
+	}
+
+	return *summary
+}
+
+// CollapsedGroup returns the outermost collapsed group containing the vertex,
+// if any.
+func (b *bouncer) CollapsedGroup(vtx *Vertex) (*Group, bool) {
+	var outermost *Group
+	for id := range b.vertex2groups[vtx.Id] {
+		for group, found := b.groups[id]; found; group, found = b.Parent(group) {
+			if b.collapsed[group.Id] {
+				outermost = group
+			}
+		}
+	}
+
+	return outermost, outermost != nil
+}
+
 func (b *bouncer) Groups(vtx *Vertex) []*Group {
 	groups := make([]*Group, 0, len(b.vertex2groups[vtx.Id]))
 	for id := range b.vertex2groups[vtx.Id] {
@@ -810,38 +1206,38 @@ func (groups progressGroups) AddGroup(w io.Writer, u *UI, b *bouncer, group *Gro
 	for i, g := range groups {
 		if i == parentIdx && addedIdx > parentIdx {
 			// line towards the right of the parent
-			fmt.Fprint(w, groupColor(parentIdx, vrbBar))
-			fmt.Fprint(w, groupColor(addedIdx, hBar))
+			fmt.Fprint(w, u.theme.GroupColor(parentIdx, u.theme.Glyphs.VRightBoldBar))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 		} else if i == parentIdx && addedIdx < parentIdx {
 			// line towards the left of the parent
-			fmt.Fprint(w, groupColor(parentIdx, vlbBar))
+			fmt.Fprint(w, u.theme.GroupColor(parentIdx, u.theme.Glyphs.VLeftBoldBar))
 			fmt.Fprint(w, " ")
 		} else if i == addedIdx && addedIdx > parentIdx {
 			// line left from parent and down to added line
-			fmt.Fprint(w, groupColor(addedIdx, trCorner))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.TopRight))
 			fmt.Fprint(w, " ")
 		} else if i == addedIdx && addedIdx < parentIdx {
 			// line up from added line and right to parent
-			fmt.Fprint(w, groupColor(addedIdx, tlCorner))
-			fmt.Fprint(w, groupColor(addedIdx, hBar))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.TopLeft))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 		} else if parentIdx != -1 && addedIdx > parentIdx && i > parentIdx && i < addedIdx {
 			// line between parent and added line
 			if g != nil {
-				fmt.Fprint(w, groupColor(i, tBar))
+				fmt.Fprint(w, u.theme.GroupColor(i, u.theme.Glyphs.Cross))
 			} else {
-				fmt.Fprint(w, groupColor(addedIdx, hBar))
+				fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 			}
-			fmt.Fprint(w, groupColor(addedIdx, hBar))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 		} else if parentIdx != -1 && addedIdx < parentIdx && i < parentIdx && i > addedIdx {
 			// line between parent and added line
 			if g != nil {
-				fmt.Fprint(w, groupColor(i, tBar))
+				fmt.Fprint(w, u.theme.GroupColor(i, u.theme.Glyphs.Cross))
 			} else {
-				fmt.Fprint(w, groupColor(addedIdx, hBar))
+				fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 			}
-			fmt.Fprint(w, groupColor(addedIdx, hBar))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 		} else if groups[i] != nil {
-			fmt.Fprint(w, groupColor(i, inactiveGroupSymbol))
+			fmt.Fprint(w, u.theme.GroupColor(i, u.theme.Glyphs.VBar))
 			fmt.Fprint(w, " ")
 		} else {
 			fmt.Fprint(w, "  ")
@@ -849,7 +1245,12 @@ func (groups progressGroups) AddGroup(w io.Writer, u *UI, b *bouncer, group *Gro
 	}
 
 	fmt.Fprintln(w)
-	groups.GroupName(w, u, group)
+
+	if b.rendered != nil {
+		b.rendered(group)
+	}
+
+	groups.GroupName(w, u, b, group, log)
 
 	return groups
 }
@@ -897,48 +1298,48 @@ func (groups progressGroups) AddVertex(w io.Writer, u *UI, allGroups map[string]
 	for i, g := range groups {
 		if i == parentIdx && addedIdx > parentIdx {
 			// line towards the right of the parent
-			fmt.Fprint(w, groupColor(parentIdx, vrbBar))
-			fmt.Fprint(w, groupColor(addedIdx, hBar))
+			fmt.Fprint(w, u.theme.GroupColor(parentIdx, u.theme.Glyphs.VRightBoldBar))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 		} else if i == parentIdx && addedIdx < parentIdx {
 			// line towards the left of the parent
-			fmt.Fprint(w, groupColor(parentIdx, vlbBar))
+			fmt.Fprint(w, u.theme.GroupColor(parentIdx, u.theme.Glyphs.VLeftBoldBar))
 			fmt.Fprint(w, " ")
 		} else if parentIdx != -1 && i == addedIdx && addedIdx > parentIdx {
 			// line left from parent and down to added line
-			fmt.Fprint(w, groupColor(addedIdx, trCorner))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.TopRight))
 			fmt.Fprint(w, " ")
 		} else if parentIdx != -1 && i == addedIdx && addedIdx < parentIdx {
 			// line up from added line and right to parent
-			fmt.Fprint(w, groupColor(addedIdx, tlCorner))
-			fmt.Fprint(w, groupColor(addedIdx, hBar))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.TopLeft))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 		} else if parentIdx != -1 && addedIdx > parentIdx && i > parentIdx && i < addedIdx {
 			// line between parent and added line
 			if g != nil {
-				fmt.Fprint(w, groupColor(i, tBar))
+				fmt.Fprint(w, u.theme.GroupColor(i, u.theme.Glyphs.Cross))
 			} else {
-				fmt.Fprint(w, groupColor(addedIdx, hBar))
+				fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 			}
-			fmt.Fprint(w, groupColor(addedIdx, hBar))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 		} else if parentIdx != -1 && addedIdx < parentIdx && i < parentIdx && i > addedIdx {
 			// line between parent and added line
 			if g != nil {
-				fmt.Fprint(w, groupColor(i, tBar))
+				fmt.Fprint(w, u.theme.GroupColor(i, u.theme.Glyphs.Cross))
 			} else {
-				fmt.Fprint(w, groupColor(addedIdx, hBar))
+				fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 			}
-			fmt.Fprint(w, groupColor(addedIdx, hBar))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 		} else if parentIdx == -1 && i == addedIdx {
-			fmt.Fprint(w, groupColor(addedIdx, emptyDot)) // TODO pointless?
-			fmt.Fprint(w, groupColor(addedIdx, hBar))
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.EmptyDot)) // TODO pointless?
+			fmt.Fprint(w, u.theme.GroupColor(addedIdx, u.theme.Glyphs.HBar))
 		} else if groups[i] != nil {
-			fmt.Fprint(w, groupColor(i, inactiveGroupSymbol))
+			fmt.Fprint(w, u.theme.GroupColor(i, u.theme.Glyphs.VBar))
 			fmt.Fprint(w, " ")
 		} else {
 			fmt.Fprint(w, "  ")
 		}
 	}
 
-	fmt.Fprintln(w, termenv.String(vtx.Name).Foreground(termenv.ANSIBrightBlack))
+	fmt.Fprintln(w, u.theme.Color(u.theme.Muted, vtx.Name))
 
 	return groups
 }
@@ -980,9 +1381,9 @@ func (groups progressGroups) VertexPrefix(w io.Writer, u *UI, vtx *Vertex, selfS
 		var symbol string
 		if g == nil {
 			if firstParentIdx != -1 && i < vtxIdx && i >= firstParentIdx {
-				fmt.Fprint(w, groupColor(firstParentIdx, hBar))
+				fmt.Fprint(w, u.theme.GroupColor(firstParentIdx, u.theme.Glyphs.HBar))
 			} else if firstParentIdx != -1 && i >= vtxIdx && i < lastParentIdx {
-				fmt.Fprint(w, groupColor(lastParentIdx, hBar))
+				fmt.Fprint(w, u.theme.GroupColor(lastParentIdx, u.theme.Glyphs.HBar))
 			} else {
 				fmt.Fprint(w, " ")
 			}
@@ -993,52 +1394,52 @@ func (groups progressGroups) VertexPrefix(w io.Writer, u *UI, vtx *Vertex, selfS
 			} else if g.Created(vtx) && i > vtxIdx {
 				if g.WitnessedAll() {
 					if i == lastParentIdx {
-						symbol = brCorner
+						symbol = u.theme.Glyphs.BottomRight
 					} else {
-						symbol = htBar
+						symbol = u.theme.Glyphs.HUpBar
 					}
 				} else {
-					symbol = vlBar
+					symbol = u.theme.Glyphs.VLeftBar
 				}
 			} else if g.Created(vtx) {
 				if g.WitnessedAll() {
 					if i == firstParentIdx {
-						symbol = blCorner
+						symbol = u.theme.Glyphs.BottomLeft
 					} else {
-						symbol = htBar
+						symbol = u.theme.Glyphs.HUpBar
 					}
 				} else {
-					symbol = vrBar
+					symbol = u.theme.Glyphs.VRightBar
 				}
 			} else if firstParentIdx != -1 && i >= firstParentIdx && i < vtxIdx {
-				symbol = tBar
+				symbol = u.theme.Glyphs.Cross
 			} else if firstParentIdx != -1 && i >= vtxIdx && i < lastParentIdx {
-				symbol = tBar
+				symbol = u.theme.Glyphs.Cross
 			} else {
-				symbol = inactiveGroupSymbol
+				symbol = u.theme.Glyphs.VBar
 			}
 
 			// respect color of the group
-			fmt.Fprint(w, groupColor(i, symbol))
+			fmt.Fprint(w, u.theme.GroupColor(i, symbol))
 		}
 
 		if firstParentIdx != -1 && vtxIdx > firstParentIdx && i >= firstParentIdx && i < vtxIdx {
 			if i+1 == vtxIdx {
-				fmt.Fprint(w, groupColor(firstParentIdx, rCaret))
+				fmt.Fprint(w, u.theme.GroupColor(firstParentIdx, u.theme.Glyphs.RightCaret))
 			} else {
-				fmt.Fprint(w, groupColor(firstParentIdx, hBar))
+				fmt.Fprint(w, u.theme.GroupColor(firstParentIdx, u.theme.Glyphs.HBar))
 			}
 		} else if firstParentIdx != -1 && vtxIdx < firstParentIdx && i >= vtxIdx && i < lastParentIdx {
 			if i == vtxIdx {
-				fmt.Fprint(w, groupColor(lastParentIdx, lCaret))
+				fmt.Fprint(w, u.theme.GroupColor(lastParentIdx, u.theme.Glyphs.LeftCaret))
 			} else {
-				fmt.Fprint(w, groupColor(lastParentIdx, hBar))
+				fmt.Fprint(w, u.theme.GroupColor(lastParentIdx, u.theme.Glyphs.HBar))
 			}
 		} else if firstParentIdx != -1 && i >= vtxIdx && i < lastParentIdx {
 			if i == vtxIdx {
-				fmt.Fprint(w, groupColor(lastParentIdx, lCaret))
+				fmt.Fprint(w, u.theme.GroupColor(lastParentIdx, u.theme.Glyphs.LeftCaret))
 			} else {
-				fmt.Fprint(w, groupColor(lastParentIdx, hBar))
+				fmt.Fprint(w, u.theme.GroupColor(lastParentIdx, u.theme.Glyphs.HBar))
 			}
 		} else {
 			fmt.Fprint(w, " ")
@@ -1048,40 +1449,48 @@ func (groups progressGroups) VertexPrefix(w io.Writer, u *UI, vtx *Vertex, selfS
 
 // TaskPrefix prints the prefix for a vertex's task.
 func (groups progressGroups) TaskPrefix(w io.Writer, u *UI, vtx *Vertex) {
-	groups.printPrefix(w, func(g progressGroup, vtx *Vertex) string {
+	groups.printPrefix(w, u, func(g progressGroup, vtx *Vertex) string {
 		if g.DirectlyContains(vtx) {
-			return taskSymbol
+			return u.theme.Glyphs.VRightBoldBar
 		}
-		return inactiveGroupSymbol
+		return u.theme.Glyphs.VBar
 	}, vtx)
 }
 
-// GroupName prints the prefix and name for newly added group.
-func (groups progressGroups) GroupName(w io.Writer, u *UI, group *Group) {
-	groups.printPrefix(w, func(g progressGroup, _ *Vertex) string {
+// GroupName prints the prefix, name, and status for newly added group.
+func (groups progressGroups) GroupName(w io.Writer, u *UI, b *bouncer, group *Group, log func(*Message)) {
+	groups.printPrefix(w, u, func(g progressGroup, _ *Vertex) string {
 		if g.ID() == group.Id {
-			if group.Weak {
-				return dEmptyCaret
+			if b.collapsed[group.Id] {
+				return u.theme.Glyphs.RightCaret
+			} else if group.Weak {
+				return u.theme.Glyphs.DownEmptyCaret
 			} else {
-				return dCaret
+				return u.theme.Glyphs.DownCaret
 			}
 		}
-		return inactiveGroupSymbol
+		return u.theme.Glyphs.VBar
 	}, nil)
-	if group.Weak {
+	if err := u.renderGroup(w, group, b.Summary(group), b.selected == group.Id, b.collapsed[group.Id]); err != nil {
+		log(&Message{
+			Level:   MessageLevel_DEBUG,
+			Message: "failed to render group",
+			Labels: []*Label{
+				{Name: "group", Value: group.Id},
+				{Name: "error", Value: err.Error()},
+			},
+		})
 		fmt.Fprintln(w, group.Name)
-	} else {
-		fmt.Fprintln(w, termenv.String(group.Name).Bold())
 	}
 }
 
 // TermPrefix prints the prefix for a vertex's terminal output.
 func (groups progressGroups) TermPrefix(w io.Writer, u *UI, vtx *Vertex) {
-	groups.printPrefix(w, func(g progressGroup, vtx *Vertex) string {
+	groups.printPrefix(w, u, func(g progressGroup, vtx *Vertex) string {
 		if g.DirectlyContains(vtx) {
-			return vbBar
+			return u.theme.Glyphs.VBoldBar
 		}
-		return inactiveGroupSymbol
+		return u.theme.Glyphs.VBar
 	}, vtx)
 }
 
@@ -1110,10 +1519,10 @@ func (groups progressGroups) Reap(w io.Writer, u *UI, active []*Vertex) progress
 	if len(reaped) > 0 {
 		for i, g := range groups {
 			if g != nil {
-				fmt.Fprint(w, groupColor(i, inactiveGroupSymbol))
+				fmt.Fprint(w, u.theme.GroupColor(i, u.theme.Glyphs.VBar))
 				fmt.Fprint(w, " ")
 			} else if reaped[i] {
-				fmt.Fprint(w, groupColor(i, htbBar))
+				fmt.Fprint(w, u.theme.GroupColor(i, u.theme.Glyphs.HUpBoldBar))
 				fmt.Fprint(w, " ")
 			} else {
 				fmt.Fprint(w, "  ")
@@ -1137,19 +1546,13 @@ func (groups progressGroups) shrink() progressGroups {
 	return groups
 }
 
-func (groups progressGroups) printPrefix(w io.Writer, sym func(progressGroup, *Vertex) string, vtx *Vertex) {
+func (groups progressGroups) printPrefix(w io.Writer, u *UI, sym func(progressGroup, *Vertex) string, vtx *Vertex) {
 	for i, g := range groups {
 		if g == nil {
 			fmt.Fprint(w, " ")
 		} else {
-			fmt.Fprint(w, groupColor(i, sym(g, vtx)))
+			fmt.Fprint(w, u.theme.GroupColor(i, sym(g, vtx)))
 		}
 		fmt.Fprint(w, " ")
 	}
 }
-
-func groupColor(i int, str string) string {
-	return termenv.String(str).Foreground(
-		termenv.ANSIColor((i+1)%7) + 1,
-	).String()
-}
@@ -4,15 +4,45 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jonboulle/clockwork"
+	"github.com/muesli/termenv"
 	"github.com/opencontainers/go-digest"
 	"github.com/sebdah/goldie/v2"
 	"github.com/stretchr/testify/require"
 	"github.com/vito/progrock"
+	"github.com/vito/progrock/tmpl"
+	progui "github.com/vito/progrock/ui"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 )
 
-var ui = progrock.DefaultUI()
+var ui = newTestUI()
+
+// newTestUI returns the default UI, but with a spinner that always shows its
+// first frame, so that renders don't depend on how long the tests take.
+func newTestUI(opts ...progrock.UIOpt) *progrock.UI {
+	u := progrock.NewUI(staticSpinner{}, opts...)
+	if err := u.ParseFS(tmpl.FS, "*.tmpl"); err != nil {
+		panic(err)
+	}
+	return u
+}
+
+type staticSpinner struct{}
+
+func (staticSpinner) Init() tea.Cmd                         { return nil }
+func (s staticSpinner) Update(tea.Msg) (tea.Model, tea.Cmd) { return s, nil }
+func (staticSpinner) View() string                          { return "" }
+func (staticSpinner) ViewFancy() string                     { return "" }
+
+func (staticSpinner) ViewFrame(frames progui.Frames) (string, time.Time, int) {
+	return frames[0], time.Time{}, 0
+}
 
 func TestEmpty(t *testing.T) {
 	tape := progrock.NewTape()
@@ -83,6 +113,73 @@ func TestSingleCanceled(t *testing.T) {
 	testGolden(t, tape)
 }
 
+func TestSingleRetried(t *testing.T) {
+	t.Run("retrying", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		vtx := runningVtx(recorder, "a", "vertex a", progrock.WithMaxAttempts(3))
+		vtx.Error(fmt.Errorf("flaked"))
+		vtx.NewAttempt()
+		fmt.Fprintln(vtx.Stdout(), "stdout retry")
+		testGolden(t, tape)
+	})
+
+	t.Run("show attempts", func(t *testing.T) {
+		tape := progrock.NewTape()
+		tape.ShowAttempts(true)
+		recorder := progrock.NewRecorder(tape)
+		vtx := runningVtx(recorder, "a", "vertex a")
+		vtx.Error(fmt.Errorf("flaked"))
+		vtx.NewAttempt()
+		fmt.Fprintln(vtx.Stdout(), "stdout retry")
+		vtx.Done(nil)
+		testGolden(t, tape)
+	})
+
+	setup := func(showAttempts bool) *progrock.Tape {
+		tape := progrock.NewTape()
+		tape.ShowAttempts(showAttempts)
+		recorder := progrock.NewRecorder(tape)
+		for _, id := range []string{"a", "b"} {
+			vtx := runningVtx(recorder, digest.Digest(id), "vertex "+id)
+			vtx.Error(fmt.Errorf("flaked"))
+			vtx.NewAttempt()
+			fmt.Fprintln(vtx.Stdout(), "stdout retry")
+			vtx.Done(nil)
+		}
+		render(t, tape)
+		tape.SelectFirst()
+		return tape
+	}
+
+	t.Run("show attempts of the selected vertex", func(t *testing.T) {
+		tape := setup(false)
+		require.False(t, tape.SelectedAttemptsShown())
+
+		tape.ToggleSelectedAttempts()
+		require.True(t, tape.SelectedAttemptsShown())
+
+		testGolden(t, tape)
+	})
+
+	t.Run("hide attempts of the selected vertex", func(t *testing.T) {
+		tape := setup(true)
+		require.True(t, tape.SelectedAttemptsShown())
+
+		tape.ToggleSelectedAttempts()
+		require.False(t, tape.SelectedAttemptsShown())
+
+		testGolden(t, tape)
+	})
+
+	t.Run("toggles attempts back", func(t *testing.T) {
+		tape := setup(false)
+		tape.ToggleSelectedAttempts()
+		tape.ToggleSelectedAttempts()
+		require.False(t, tape.SelectedAttemptsShown())
+	})
+}
+
 func TestSingleCompleted(t *testing.T) {
 	t.Run("no show all output", func(t *testing.T) {
 		tape := progrock.NewTape()
@@ -192,6 +289,327 @@ func TestGroupedWeakStrong(t *testing.T) {
 	testGolden(t, tape)
 }
 
+func TestGroupStatus(t *testing.T) {
+	t.Run("rollup", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		group := recorder.WithGroup("group a")
+		group.Vertex("a", "vertex a").Done(fmt.Errorf("nope"))
+		cached := group.Vertex("b", "vertex b")
+		cached.Cached()
+		cached.Done(nil)
+		runningVtx(group.WithGroup("sub-group"), "c", "vertex c")
+		testGolden(t, tape)
+	})
+
+	t.Run("errored", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		group := recorder.WithGroup("group a")
+		group.Vertex("a", "vertex a").Done(nil)
+		group.Done(fmt.Errorf("nope"))
+		testGolden(t, tape)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		group := recorder.WithGroup("group a")
+		group.Vertex("a", "vertex a").Done(nil)
+		group.Done(context.Canceled)
+		testGolden(t, tape)
+	})
+}
+
+func TestSelection(t *testing.T) {
+	t.Run("selected vertex is highlighted", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		recorder.Vertex("a", "vertex a").Done(nil)
+		recorder.Vertex("b", "vertex b").Done(nil)
+		render(t, tape)
+
+		tape.SelectPrevious()
+		tape.SelectPrevious()
+		line, selected := tape.SelectedLine()
+		require.True(t, selected)
+		require.Equal(t, 0, line)
+
+		testGolden(t, tape)
+	})
+
+	t.Run("moving down with nothing selected selects the first line", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		recorder.Vertex("a", "vertex a").Done(nil)
+		recorder.Vertex("b", "vertex b").Done(nil)
+		recorder.Vertex("c", "vertex c").Done(nil)
+		render(t, tape)
+
+		tape.SelectNext()
+		line, selected := tape.SelectedLine()
+		require.True(t, selected)
+		require.Equal(t, 0, line)
+
+		tape.SelectNext()
+		line, selected = tape.SelectedLine()
+		require.True(t, selected)
+		require.Equal(t, 1, line)
+	})
+
+	t.Run("moving up with nothing selected selects the last line", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		recorder.Vertex("a", "vertex a").Done(nil)
+		recorder.Vertex("b", "vertex b").Done(nil)
+		recorder.Vertex("c", "vertex c").Done(nil)
+		render(t, tape)
+
+		tape.SelectPrevious()
+		line, selected := tape.SelectedLine()
+		require.True(t, selected)
+		require.Equal(t, 2, line)
+	})
+
+	t.Run("expanding a completed vertex shows its output", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		runningVtx(recorder, "a", "vertex a").Done(nil)
+		runningVtx(recorder, "b", "vertex b")
+		render(t, tape)
+
+		tape.SelectFirst()
+		tape.ToggleSelected()
+
+		testGolden(t, tape)
+	})
+
+	t.Run("collapsing a running vertex hides its output and tasks", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		vtx := runningVtx(recorder, "a", "vertex a")
+		vtx.Task("some task")
+		render(t, tape)
+
+		tape.SelectLast()
+		tape.ToggleSelected()
+
+		testGolden(t, tape)
+	})
+
+	t.Run("groups can be selected", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		recorder.WithGroup("group a").Vertex("a", "vertex a").Done(nil)
+		recorder.Vertex("b", "vertex b").Done(nil)
+		render(t, tape)
+
+		tape.SelectFirst()
+		line, selected := tape.SelectedLine()
+		require.True(t, selected)
+		require.Equal(t, 1, line)
+
+		testGolden(t, tape)
+	})
+}
+
+func TestCollapsedGroups(t *testing.T) {
+	t.Run("collapsed by the user", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		group := recorder.WithGroup("group a")
+		group.Vertex("a", "vertex a").Done(nil)
+		runningVtx(group, "b", "vertex b")
+		recorder.Vertex("c", "vertex c").Done(nil)
+		render(t, tape)
+
+		tape.SelectFirst()
+		tape.ToggleSelected()
+
+		testGolden(t, tape)
+	})
+
+	t.Run("successful groups are auto-collapsed", func(t *testing.T) {
+		tape := progrock.NewTape()
+		tape.AutoCollapse(true)
+		recorder := progrock.NewRecorder(tape)
+		done := recorder.WithGroup("done group")
+		done.Vertex("a", "vertex a").Done(nil)
+		done.WithGroup("sub-group").Vertex("b", "vertex b").Done(nil)
+		failed := recorder.WithGroup("failed group")
+		failed.Vertex("c", "vertex c").Done(fmt.Errorf("nope"))
+		running := recorder.WithGroup("running group")
+		running.Vertex("d", "vertex d").Done(nil)
+		runningVtx(running, "e", "vertex e")
+		testGolden(t, tape)
+	})
+
+	t.Run("expanded by the user", func(t *testing.T) {
+		tape := progrock.NewTape()
+		tape.AutoCollapse(true)
+		recorder := progrock.NewRecorder(tape)
+		recorder.WithGroup("group a").Vertex("a", "vertex a").Done(nil)
+		recorder.Vertex("b", "vertex b").Done(nil)
+		render(t, tape)
+
+		tape.SelectFirst()
+		tape.ToggleSelected()
+
+		testGolden(t, tape)
+	})
+}
+
+func TestFilter(t *testing.T) {
+	setup := func() *progrock.Tape {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		build := recorder.WithGroup("build", progrock.WithLabels(&progrock.Label{Name: "arch", Value: "arm64"}))
+		build.Vertex("a", "compile foo").Done(nil)
+		build.Vertex("b", "compile bar").Done(fmt.Errorf("nope"))
+		test := recorder.WithGroup("test")
+		cached := test.Vertex("c", "test foo")
+		cached.Cached()
+		cached.Done(nil)
+		runningVtx(test, "d", "test bar")
+		return tape
+	}
+
+	for name, query := range map[string]string{
+		"vertex name":   "foo",
+		"group name":    "TEST",
+		"status":        "status:failed",
+		"label":         "label:arch=arm64",
+		"multiple":      "bar status:running",
+		"no matches":    "bogus",
+		"cleared query": " ",
+	} {
+		query := query
+		t.Run(name, func(t *testing.T) {
+			tape := setup()
+			tape.Filter(query)
+			testGolden(t, tape)
+		})
+	}
+}
+
+func TestMessageLevel(t *testing.T) {
+	setup := func() *progrock.Tape {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		recorder.Vertex("a", "vertex a").Done(nil)
+		recorder.Debug("debug message")
+		recorder.Warn("warning message")
+		recorder.Error("error message")
+		return tape
+	}
+
+	t.Run("hides debug messages by default", func(t *testing.T) {
+		testGolden(t, setup())
+	})
+
+	t.Run("shows earlier debug messages", func(t *testing.T) {
+		tape := setup()
+		tape.MessageLevel(progrock.MessageLevel_DEBUG)
+		require.Equal(t, progrock.MessageLevel_DEBUG, tape.DisplaySettings().MessageLevel)
+		testGolden(t, tape)
+	})
+
+	t.Run("hides earlier warnings", func(t *testing.T) {
+		tape := setup()
+		tape.MessageLevel(progrock.MessageLevel_ERROR)
+		testGolden(t, tape)
+	})
+
+	t.Run("keeps older messages that are still shown at a higher level", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		recorder.Vertex("a", "vertex a").Done(nil)
+		recorder.Error("error message")
+		for i := 1; i <= 100; i++ {
+			recorder.Debug(fmt.Sprintf("debug message %d", i))
+		}
+
+		tape.MessageLevel(progrock.MessageLevel_DEBUG)
+		buf := new(bytes.Buffer)
+		require.NoError(t, tape.Render(buf, ui))
+		require.NotContains(t, buf.String(), "error message")
+		require.NotContains(t, buf.String(), "debug message 90")
+		require.Contains(t, buf.String(), "debug message 91")
+		require.Contains(t, buf.String(), "debug message 100")
+
+		tape.MessageLevel(progrock.MessageLevel_ERROR)
+		buf.Reset()
+		require.NoError(t, tape.Render(buf, ui))
+		require.Contains(t, buf.String(), "error message")
+		require.NotContains(t, buf.String(), "debug message")
+	})
+}
+
+func TestTheme(t *testing.T) {
+	setup := func() *progrock.Tape {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(progrock.WithClock(clock))
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+		group := recorder.WithGroup("group")
+		group.Vertex("a", "vertex a").Done(nil)
+		group.Vertex("b", "vertex b").Done(fmt.Errorf("nope"))
+		runningVtx(group, "c", "vertex c")
+		recorder.Warn("careful", progrock.WithMessageLabels(&progrock.Label{Name: "foo", Value: "bar"}))
+		return tape
+	}
+
+	noColor := progui.DarkTheme
+	noColor.Profile = termenv.Ascii
+
+	for name, theme := range map[string]progui.Theme{
+		"light":    progui.LightTheme,
+		"no color": noColor,
+	} {
+		theme := theme
+		t.Run(name, func(t *testing.T) {
+			testGoldenUI(t, setup(), newTestUI(progrock.WithTheme(theme)))
+		})
+	}
+}
+
+func TestASCII(t *testing.T) {
+	asciiUI := newTestUI(progrock.WithGlyphs(progui.ASCIIGlyphs))
+	asciiUI.SetWindowSize(80, 24)
+
+	t.Run("groups", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(progrock.WithClock(clock))
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+		runningVtx(recorder.WithGroup("group a"), "a", "vertex a").Done(nil)
+		b := runningVtx(recorder.WithGroup("group b"), "b", "vertex b")
+		runningVtx(recorder.WithGroup("group c"), "c", "vertex c").Done(nil)
+		b.Done(fmt.Errorf("nope"))
+		runningVtx(recorder.WithGroup("group a").WithGroup("group a.a", progrock.Weak()), "z", "vertex z")
+		testGoldenUI(t, tape, asciiUI)
+	})
+
+	t.Run("inputs", func(t *testing.T) {
+		tape := progrock.NewTape()
+		recorder := progrock.NewRecorder(tape)
+		vtx := recorder.Vertex("a", "vertex a")
+		vtx.Output("foo")
+		vtx.Done(nil)
+		recorder.WithGroup("group 1").Vertex("b", "vertex b", progrock.WithInputs("foo")).Done(nil)
+		recorder.Vertex("c", "vertex c", progrock.WithInputs("a")).Done(nil)
+		testGoldenUI(t, tape, asciiUI)
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(progrock.WithClock(clock))
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+		vtx := recorder.Vertex("a", "vertex a")
+		vtx.Task("task 1").Done(nil)
+		vtx.ProgressTask(100, "task 2").Current(25)
+		testGoldenUI(t, tape, asciiUI)
+	})
+}
+
 func TestInputSameGroup(t *testing.T) {
 	t.Run("no verbose edges", func(t *testing.T) {
 		tape := progrock.NewTape()
@@ -583,8 +1001,9 @@ func TestVertexInputsCrossGapRight(t *testing.T) {
 }
 
 func TestAutoResize(t *testing.T) {
-	tape := progrock.NewTape()
-	recorder := progrock.NewRecorder(tape)
+	clock := clockwork.NewFakeClock()
+	tape := progrock.NewTape(progrock.WithClock(clock))
+	recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
 
 	t.Run("initially unbounded", func(t *testing.T) {
 		vtx := recorder.Vertex("long", "long lines")
@@ -607,6 +1026,181 @@ func TestAutoResize(t *testing.T) {
 	})
 }
 
+func TestClock(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	tape := progrock.NewTape(progrock.WithClock(clock))
+	recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+
+	done := recorder.Vertex("done", "completed vertex")
+	clock.Advance(1500 * time.Millisecond)
+	done.Done(nil)
+
+	running := recorder.Vertex("running", "running vertex")
+	task := running.Task("some task")
+	clock.Advance(5 * time.Second)
+	task.Done(nil)
+	clock.Advance(20 * time.Second)
+
+	testGolden(t, tape)
+}
+
+func TestUIClock(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	u := newTestUI(progrock.WithClock(clock))
+
+	started := timestamppb.New(clock.Now())
+	vtx := &progrock.Vertex{Id: "a", Name: "vertex a", Started: started}
+	task := &progrock.VertexTask{Vertex: "a", Name: "some task", Started: started}
+	attempt := &progrock.VertexAttempt{Started: started}
+
+	clock.Advance(1500 * time.Millisecond)
+
+	require.Equal(t, 1500*time.Millisecond, vtx.DurationAt(clock.Now()))
+	require.Equal(t, 1500*time.Millisecond, task.DurationAt(clock.Now()))
+	require.Equal(t, 1500*time.Millisecond, attempt.DurationAt(clock.Now()))
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, u.RenderVertex(buf, vtx))
+	require.Contains(t, buf.String(), "[1.50s]")
+
+	buf.Reset()
+	require.NoError(t, u.RenderTask(buf, task))
+	require.Contains(t, buf.String(), "[1.50s]")
+
+	buf.Reset()
+	require.NoError(t, u.RenderAttempt(buf, 1, attempt))
+	require.Contains(t, buf.String(), "[1.50s]")
+}
+
+func TestScrollback(t *testing.T) {
+	tape := progrock.NewTape(progrock.WithScrollback(3))
+	recorder := progrock.NewRecorder(tape)
+
+	vtx := recorder.Vertex("a", "chatty vertex")
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(vtx.Stdout(), "line %d\n", i)
+	}
+
+	testGolden(t, tape)
+}
+
+func TestLogRetention(t *testing.T) {
+	t.Run("expired logs of successful vertexes are evicted", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(
+			progrock.WithClock(clock),
+			progrock.WithLogExpiry(time.Minute),
+		)
+		tape.ShowAllOutput(true)
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+
+		runningVtx(recorder, "a", "vertex a").Done(nil)
+		runningVtx(recorder, "b", "vertex b").Done(fmt.Errorf("nope"))
+
+		clock.Advance(2 * time.Minute)
+		recorder.Vertex("c", "vertex c")
+
+		testGolden(t, tape)
+	})
+
+	t.Run("least recently used logs are evicted beyond the limit", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(
+			progrock.WithClock(clock),
+			progrock.WithLogLimit(100),
+		)
+		tape.ShowAllOutput(true)
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+
+		runningVtx(recorder, "a", "vertex a").Done(nil)
+		clock.Advance(time.Second)
+		runningVtx(recorder, "b", "vertex b").Done(nil)
+		clock.Advance(time.Second)
+		runningVtx(recorder, "c", "vertex c").Done(nil)
+
+		testGolden(t, tape)
+	})
+
+	t.Run("spilled logs are paged back in", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(
+			progrock.WithClock(clock),
+			progrock.WithLogExpiry(time.Minute),
+			progrock.WithLogSpill(t.TempDir()),
+		)
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+
+		runningVtx(recorder, "a", "vertex a").Done(nil)
+
+		clock.Advance(2 * time.Minute)
+		recorder.Vertex("b", "vertex b")
+
+		vertices := tape.Vertices()
+		require.Len(t, vertices, 2)
+		require.Empty(t, tape.Activity(vertices[0]).LastLine, "logs should have been evicted")
+
+		tape.ShowAllOutput(true)
+		testGolden(t, tape)
+	})
+
+	t.Run("spilled logs are paged back in on close", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(
+			progrock.WithClock(clock),
+			progrock.WithLogExpiry(time.Minute),
+			progrock.WithLogSpill(t.TempDir()),
+		)
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+
+		a := runningVtx(recorder, "a", "vertex a")
+		a.Done(nil)
+
+		clock.Advance(2 * time.Minute)
+		recorder.Vertex("b", "vertex b")
+
+		// status updates alone don't need the logs
+		a.Complete()
+
+		vertices := tape.Vertices()
+		require.Len(t, vertices, 2)
+		require.Empty(t, tape.Activity(vertices[0]).LastLine, "logs should not have been paged in")
+
+		require.NoError(t, tape.Close())
+		require.Contains(t, tape.Activity(vertices[0]).LastLine, "stderr 2")
+	})
+
+	t.Run("stale spilled logs are compacted", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tape := progrock.NewTape(
+			progrock.WithClock(clock),
+			progrock.WithLogExpiry(time.Minute),
+			progrock.WithLogSpill(t.TempDir()),
+		)
+		recorder := progrock.NewRecorder(tape, progrock.WithClock(clock))
+
+		a := recorder.Vertex("a", "vertex a")
+		a.Done(nil)
+
+		line := strings.Repeat("x", 100)
+		for i := 1; i <= 5; i++ {
+			// writing pages the logs back in, and they're spilled again once
+			// they expire, leaving the previous spill behind
+			for j := 0; j < 640; j++ {
+				fmt.Fprintln(a.Stdout(), line)
+			}
+			fmt.Fprintf(a.Stdout(), "round %d\n", i)
+
+			clock.Advance(2 * time.Minute)
+			recorder.Vertex(digest.Digest(fmt.Sprintf("b%d", i)), "vertex b")
+
+			require.Empty(t, tape.Activity(tape.Vertices()[0]).LastLine, "logs should have been evicted")
+		}
+
+		require.NoError(t, tape.Close())
+		require.Contains(t, tape.Activity(tape.Vertices()[0]).LastLine, "round 5")
+	})
+}
+
 func TestMessages(t *testing.T) {
 	t.Run("debug messages are not shown by default", func(t *testing.T) {
 		tape := progrock.NewTape()
@@ -653,6 +1247,23 @@ func testGolden(t *testing.T, tape *progrock.Tape) {
 	g.Assert(t, t.Name(), buf.Bytes())
 }
 
+func testGoldenUI(t *testing.T, tape *progrock.Tape, u *progrock.UI) {
+	buf := new(bytes.Buffer)
+	tape.SetWindowSize(80, 24)
+
+	err := tape.Render(buf, u)
+	require.NoError(t, err)
+
+	g := goldie.New(t)
+	g.Assert(t, t.Name(), buf.Bytes())
+}
+
+func render(t *testing.T, tape *progrock.Tape) {
+	tape.SetWindowSize(80, 24)
+	err := tape.Render(io.Discard, ui)
+	require.NoError(t, err)
+}
+
 func testGoldenAutoResize(t *testing.T, tape *progrock.Tape) {
 	buf := new(bytes.Buffer)
 	tape.Render(buf, ui)
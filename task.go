@@ -1,11 +1,21 @@
 package progrock
 
-import timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+import (
+	"sync"
 
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TaskRecorder records updates pertaining to a vertex's task.
+//
+// It is safe for concurrent use.
 type TaskRecorder struct {
 	*VertexRecorder
 
 	Task *VertexTask
+
+	// guards Task
+	taskL sync.Mutex
 }
 
 func (recorder *TaskRecorder) Wrap(f func() error) error {
@@ -24,13 +34,19 @@ func (recorder *TaskRecorder) Done(err error) {
 }
 
 func (recorder *TaskRecorder) Start() {
-	now := Clock.Now()
+	recorder.taskL.Lock()
+	defer recorder.taskL.Unlock()
+
+	now := recorder.Recorder.now()
 	recorder.Task.Started = timestamppb.New(now)
 	recorder.sync()
 }
 
 func (recorder *TaskRecorder) Complete() {
-	now := Clock.Now()
+	recorder.taskL.Lock()
+	defer recorder.taskL.Unlock()
+
+	now := recorder.Recorder.now()
 
 	if recorder.Task.Started == nil {
 		recorder.Task.Started = timestamppb.New(now)
@@ -42,16 +58,23 @@ func (recorder *TaskRecorder) Complete() {
 }
 
 func (recorder *TaskRecorder) Progress(cur, total int64) {
+	recorder.taskL.Lock()
+	defer recorder.taskL.Unlock()
+
 	recorder.Task.Current = cur
 	recorder.Task.Total = total
 	recorder.sync()
 }
 
 func (recorder *TaskRecorder) Current(cur int64) {
+	recorder.taskL.Lock()
+	defer recorder.taskL.Unlock()
+
 	recorder.Task.Current = cur
 	recorder.sync()
 }
 
+// sync sends an update for the task. It must be called with taskL held.
 func (recorder *TaskRecorder) sync() {
 	recorder.Recorder.Record(&StatusUpdate{
 		Tasks: []*VertexTask{
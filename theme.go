@@ -0,0 +1,44 @@
+package progrock
+
+import "github.com/vito/progrock/ui"
+
+// ThemeOpt sets the colors and glyphs used for rendering. It may be passed to
+// NewUI or DefaultUI.
+type ThemeOpt struct {
+	theme ui.Theme
+}
+
+// WithTheme sets the colors and glyphs used for rendering, in place of
+// ui.DarkTheme.
+func WithTheme(theme ui.Theme) ThemeOpt {
+	return ThemeOpt{theme}
+}
+
+func (opt ThemeOpt) applyUI(u *UI) {
+	u.SetTheme(opt.theme)
+}
+
+// GlyphsOpt sets the glyphs used for rendering, keeping the rest of the
+// theme. It may be passed to NewUI or DefaultUI.
+type GlyphsOpt struct {
+	glyphs ui.Glyphs
+}
+
+// WithGlyphs sets the glyphs used for rendering, e.g. ui.ASCIIGlyphs.
+func WithGlyphs(glyphs ui.Glyphs) GlyphsOpt {
+	return GlyphsOpt{glyphs}
+}
+
+func (opt GlyphsOpt) applyUI(u *UI) {
+	theme := u.Theme()
+	theme.Glyphs = opt.glyphs
+	u.SetTheme(theme)
+}
+
+// setSpinnerFrames passes the theme's frames along to spinners that animate
+// them.
+func setSpinnerFrames(spinner ui.Spinner, frames ui.Frames) {
+	if rave, ok := spinner.(*ui.Rave); ok {
+		rave.Frames = frames
+	}
+}
@@ -1,7 +1,16 @@
 package ui
 
-import "github.com/charmbracelet/bubbles/key"
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// KeyMap is the set of key bindings used by the UI.
 type KeyMap struct {
 	Rave         key.Binding
 	EndRave      key.Binding
@@ -11,8 +20,27 @@ type KeyMap struct {
 	Help         key.Binding
 	Quit         key.Binding
 
-	Up   key.Binding
-	Down key.Binding
+	Up             key.Binding
+	Down           key.Binding
+	Expand         key.Binding
+	ExpandAttempts key.Binding
+	Open           key.Binding
+	Filter         key.Binding
+
+	// display toggles
+	ToggleInternal key.Binding
+	ToggleFocus    key.Binding
+	ToggleEdges    key.Binding
+	ToggleOutput   key.Binding
+	CycleMessages  key.Binding
+
+	// pager keys
+	Back       key.Binding
+	Follow     key.Binding
+	Search     key.Binding
+	NextMatch  key.Binding
+	PrevMatch  key.Binding
+	FirstError key.Binding
 
 	Home, End        key.Binding
 	PageUp, PageDown key.Binding
@@ -25,10 +53,30 @@ func (k KeyMap) ShortHelp() []key.Binding {
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Help, k.Quit, k.Debug},
+		{k.Up, k.Down, k.Expand, k.ExpandAttempts, k.Open, k.Filter},
+		{k.ToggleInternal, k.ToggleFocus, k.ToggleEdges, k.ToggleOutput, k.CycleMessages},
 		{k.Rave, k.EndRave, k.ForwardRave, k.BackwardRave},
 	}
 }
 
+// PagerKeyMap is the help.KeyMap for the log pager.
+type PagerKeyMap struct {
+	KeyMap
+}
+
+func (k PagerKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Back, k.Search, k.Help}
+}
+
+func (k PagerKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Back, k.Help, k.Quit},
+		{k.Up, k.Down, k.PageUp, k.PageDown, k.Home, k.End},
+		{k.Follow, k.Search, k.NextMatch, k.PrevMatch, k.FirstError},
+	}
+}
+
+// Keys is the default KeyMap.
 var Keys = KeyMap{
 	Help: key.NewBinding(
 		key.WithKeys("?"),
@@ -67,6 +115,66 @@ var Keys = KeyMap{
 		key.WithKeys("down", "j"),
 		key.WithHelp("↓/j", "move down"),
 	),
+	Expand: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "expand/collapse"),
+	),
+	ExpandAttempts: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "previous attempts"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open logs"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "filter"),
+	),
+	ToggleInternal: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "internal"),
+	),
+	ToggleFocus: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "focus"),
+	),
+	ToggleEdges: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "verbose edges"),
+	),
+	ToggleOutput: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "all output"),
+	),
+	CycleMessages: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "messages"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Follow: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "follow"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	NextMatch: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "next match"),
+	),
+	PrevMatch: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "previous match"),
+	),
+	FirstError: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "first error"),
+	),
 	Home: key.NewBinding(
 		key.WithKeys("home"),
 		key.WithHelp("home", "go to top"),
@@ -84,3 +192,142 @@ var Keys = KeyMap{
 		key.WithHelp("pgup", "page up"),
 	),
 }
+
+// keyNames maps the names used in key map config files to their bindings.
+func (k *KeyMap) keyNames() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"help":            &k.Help,
+		"quit":            &k.Quit,
+		"debug":           &k.Debug,
+		"rave":            &k.Rave,
+		"end_rave":        &k.EndRave,
+		"forward_rave":    &k.ForwardRave,
+		"backward_rave":   &k.BackwardRave,
+		"up":              &k.Up,
+		"down":            &k.Down,
+		"page_up":         &k.PageUp,
+		"page_down":       &k.PageDown,
+		"home":            &k.Home,
+		"end":             &k.End,
+		"expand":          &k.Expand,
+		"expand_attempts": &k.ExpandAttempts,
+		"open":            &k.Open,
+		"filter":          &k.Filter,
+		"toggle_internal": &k.ToggleInternal,
+		"toggle_focus":    &k.ToggleFocus,
+		"toggle_edges":    &k.ToggleEdges,
+		"toggle_output":   &k.ToggleOutput,
+		"cycle_messages":  &k.CycleMessages,
+		"back":            &k.Back,
+		"follow":          &k.Follow,
+		"search":          &k.Search,
+		"next_match":      &k.NextMatch,
+		"prev_match":      &k.PrevMatch,
+		"first_error":     &k.FirstError,
+	}
+}
+
+// keyContexts lists the bindings which are active at the same time, and so
+// must not share any keys.
+//
+// Quit is left out of the pager since Back is matched first, which is how esc
+// goes back rather than quitting.
+var keyContexts = map[string][]string{
+	"tree": {
+		"help", "quit", "debug",
+		"rave", "end_rave", "forward_rave", "backward_rave",
+		"up", "down", "page_up", "page_down", "home", "end",
+		"expand", "expand_attempts", "open", "filter",
+		"toggle_internal", "toggle_focus", "toggle_edges", "toggle_output", "cycle_messages",
+	},
+	"pager": {
+		"help", "back",
+		"up", "down", "page_up", "page_down", "home", "end",
+		"follow", "search", "next_match", "prev_match", "first_error",
+	},
+}
+
+// Validate returns an error describing every key which is bound to more than
+// one action at a time.
+func (k KeyMap) Validate() error {
+	bindings := k.keyNames()
+
+	var conflicts []string
+	for _, context := range []string{"tree", "pager"} {
+		bound := map[string]string{}
+		for _, name := range keyContexts[context] {
+			binding := bindings[name]
+			if !binding.Enabled() {
+				continue
+			}
+
+			for _, key := range binding.Keys() {
+				if other, found := bound[key]; found {
+					conflicts = append(conflicts, fmt.Sprintf("%s: %q is bound to both %s and %s", context, key, other, name))
+					continue
+				}
+
+				bound[key] = name
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("conflicting key bindings: %s", strings.Join(conflicts, "; "))
+	}
+
+	return nil
+}
+
+// LoadKeyMap loads key bindings from a JSON config file mapping action names
+// to the keys that trigger them, for example:
+//
+//	{"quit": ["ctrl+c"], "toggle_internal": ["I"]}
+//
+// Actions missing from the file keep their default bindings from Keys, and
+// actions bound to an empty list are disabled. An error is returned for
+// unknown actions or conflicting bindings.
+func LoadKeyMap(path string) (KeyMap, error) {
+	keys := Keys
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return keys, err
+	}
+
+	var config map[string][]string
+	if err := json.Unmarshal(content, &config); err != nil {
+		return keys, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	bindings := keys.keyNames()
+
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		binding, found := bindings[name]
+		if !found {
+			return keys, fmt.Errorf("%s: unknown action %q", path, name)
+		}
+
+		keyList := config[name]
+		if len(keyList) == 0 {
+			binding.SetEnabled(false)
+			continue
+		}
+
+		binding.SetKeys(keyList...)
+		binding.SetHelp(strings.Join(keyList, "/"), binding.Help().Desc)
+		binding.SetEnabled(true)
+	}
+
+	if err := keys.Validate(); err != nil {
+		return keys, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,52 @@
+package ui_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vito/progrock/ui"
+)
+
+func TestKeysValidate(t *testing.T) {
+	require.NoError(t, ui.Keys.Validate())
+
+	keys := ui.Keys
+	keys.Open.SetKeys("q")
+	require.ErrorContains(t, keys.Validate(), `tree: "q" is bound to both quit and open`)
+}
+
+func TestLoadKeyMap(t *testing.T) {
+	load := func(t *testing.T, config string) (ui.KeyMap, error) {
+		path := filepath.Join(t.TempDir(), "keys.json")
+		require.NoError(t, os.WriteFile(path, []byte(config), 0600))
+		return ui.LoadKeyMap(path)
+	}
+
+	t.Run("overrides bindings", func(t *testing.T) {
+		keys, err := load(t, `{"quit": ["ctrl+c"], "toggle_internal": ["I", "ctrl+i"]}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"ctrl+c"}, keys.Quit.Keys())
+		require.Equal(t, []string{"I", "ctrl+i"}, keys.ToggleInternal.Keys())
+		require.Equal(t, "I/ctrl+i", keys.ToggleInternal.Help().Key)
+		require.Equal(t, ui.Keys.Help.Keys(), keys.Help.Keys())
+	})
+
+	t.Run("disables bindings", func(t *testing.T) {
+		keys, err := load(t, `{"debug": []}`)
+		require.NoError(t, err)
+		require.False(t, keys.Debug.Enabled())
+		require.True(t, ui.Keys.Debug.Enabled())
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		_, err := load(t, `{"bogus": ["b"]}`)
+		require.ErrorContains(t, err, `unknown action "bogus"`)
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		_, err := load(t, `{"follow": ["n"]}`)
+		require.ErrorContains(t, err, `pager: "n" is bound to both follow and next_match`)
+	})
+}
@@ -32,6 +32,9 @@ type Rave struct {
 	// Show extra details useful for debugging a desynced rave.
 	ShowDetails bool
 
+	// Key bindings for controlling the rave.
+	Keys KeyMap
+
 	// Address (host:port) on which to listen for auth callbacks.
 	AuthCallbackAddr string
 
@@ -113,6 +116,7 @@ var FadeFrames = [FramesPerBeat]string{
 func NewRave() *Rave {
 	r := &Rave{
 		Frames: MeterFrames,
+		Keys:   Keys,
 
 		spotifyAuthCh: make(chan *spotify.Client),
 	}
@@ -253,19 +257,19 @@ func (rave *Rave) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// NB: these are captured and forwarded at the outer level.
 	case tea.KeyMsg:
 		switch {
-		case key.Matches(msg, Keys.Rave):
+		case key.Matches(msg, rave.Keys.Rave):
 			return rave, rave.Sync()
-		case key.Matches(msg, Keys.EndRave):
+		case key.Matches(msg, rave.Keys.EndRave):
 			return rave, rave.Desync()
-		case key.Matches(msg, Keys.ForwardRave):
+		case key.Matches(msg, rave.Keys.ForwardRave):
 			rave.start = rave.start.Add(-100 * time.Millisecond)
 			rave.pos = 0 // reset and recalculate
 			return rave, nil
-		case key.Matches(msg, Keys.BackwardRave):
+		case key.Matches(msg, rave.Keys.BackwardRave):
 			rave.start = rave.start.Add(100 * time.Millisecond)
 			rave.pos = 0 // reset and recalculate
 			return rave, nil
-		case key.Matches(msg, Keys.Debug):
+		case key.Matches(msg, rave.Keys.Debug):
 			rave.ShowDetails = !rave.ShowDetails
 			return rave, nil
 		}
@@ -0,0 +1,329 @@
+package ui
+
+import (
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/muesli/termenv"
+)
+
+// Theme configures the colors and glyphs used to render the UI.
+//
+// Colors are ANSI color numbers ("0" to "15"), 256-color numbers ("16" to
+// "255"), or hex codes ("#ff8700"). They are converted to the nearest color
+// supported by the Profile, and dropped entirely for termenv.Ascii.
+type Theme struct {
+	// Color profile of the terminal.
+	Profile termenv.Profile
+
+	// Colors for the status of vertexes, tasks, and groups.
+	Running   string
+	Completed string
+	Cached    string
+	Failed    string
+	Canceled  string
+
+	// Color for durations, separators, internal vertexes, and other secondary
+	// text.
+	Muted string
+
+	// Color for highlights like the filter query.
+	Accent string
+
+	// Colors for message levels.
+	Debug   string
+	Warning string
+	Error   string
+
+	// Colors cycled through for each group in the tree.
+	Groups []string
+
+	// Colors for the plain console output, where it differs from the tree.
+	Console ConsoleColors
+
+	// Glyphs used to draw the tree.
+	Glyphs Glyphs
+}
+
+// ConsoleColors are the colors used by the plain console output. Any left
+// empty fall back to the Theme's Accent, first group, Cached, and Canceled
+// colors respectively.
+type ConsoleColors struct {
+	VertexID string
+	Group    string
+	Cached   string
+	Canceled string
+}
+
+// Glyphs are the symbols used to draw the tree.
+type Glyphs struct {
+	// Symbol for a completed vertex.
+	Block string
+
+	// Symbol for a vertex's own group, which is never drawn in practice.
+	EmptyDot string
+
+	// Lines and junctions connecting groups and vertexes.
+	VBar           string
+	VBoldBar       string
+	HBar           string
+	Cross          string
+	TopLeft        string
+	TopRight       string
+	BottomLeft     string
+	BottomRight    string
+	VLeftBar       string
+	VRightBar      string
+	VLeftBoldBar   string
+	VRightBoldBar  string
+	HUpBar         string
+	HUpBoldBar     string
+	LeftCaret      string
+	RightCaret     string
+	DownCaret      string
+	DownEmptyCaret string
+
+	// Separator between items in a status line.
+	Separator string
+
+	// Gutter to the left of trailer lines.
+	Gutter string
+
+	// Spinner frames for the status line and for running vertexes.
+	Meter Frames
+	Fade  Frames
+
+	// Runes for the filled and empty parts of progress bars.
+	BarFull  rune
+	BarEmpty rune
+}
+
+// UnicodeGlyphs draws the tree with Unicode box-drawing characters.
+var UnicodeGlyphs = Glyphs{
+	Block:          "█",
+	EmptyDot:       "○",
+	VBar:           "│",
+	VBoldBar:       "┃",
+	HBar:           "─",
+	Cross:          "┼",
+	TopLeft:        "╭",
+	TopRight:       "╮",
+	BottomLeft:     "╰",
+	BottomRight:    "╯",
+	VLeftBar:       "┤",
+	VRightBar:      "├",
+	VLeftBoldBar:   "┫",
+	VRightBoldBar:  "┣",
+	HUpBar:         "┴",
+	HUpBoldBar:     "┻",
+	LeftCaret:      "◀",
+	RightCaret:     "▶",
+	DownCaret:      "▼",
+	DownEmptyCaret: "▽",
+	Separator:      "•",
+	Gutter:         "▕",
+	Meter:          MeterFrames,
+	Fade:           FadeFrames,
+	BarFull:        '█',
+	BarEmpty:       '░',
+}
+
+// ASCIIGlyphs draws the tree with ASCII characters only, for serial consoles,
+// log viewers, and locales that can't display Unicode.
+var ASCIIGlyphs = Glyphs{
+	Block:          "#",
+	EmptyDot:       "o",
+	VBar:           "|",
+	VBoldBar:       "|",
+	HBar:           "-",
+	Cross:          "+",
+	TopLeft:        ".",
+	TopRight:       ".",
+	BottomLeft:     "'",
+	BottomRight:    "'",
+	VLeftBar:       "+",
+	VRightBar:      "+",
+	VLeftBoldBar:   "+",
+	VRightBoldBar:  "+",
+	HUpBar:         "+",
+	HUpBoldBar:     "'",
+	LeftCaret:      "<",
+	RightCaret:     ">",
+	DownCaret:      "V",
+	DownEmptyCaret: "v",
+	Separator:      "*",
+	Gutter:         "|",
+	Meter:          ASCIIMeterFrames,
+	Fade:           ASCIIFadeFrames,
+	BarFull:        '#',
+	BarEmpty:       '.',
+}
+
+// ASCIIMeterFrames is the ASCII equivalent of MeterFrames.
+var ASCIIMeterFrames = Frames{
+	0: "#",
+	1: "#",
+	2: "#",
+	3: "=",
+	4: "=",
+	5: "-",
+	6: "-",
+	7: "_",
+	8: "_",
+	9: " ",
+}
+
+// ASCIIFadeFrames is the ASCII equivalent of FadeFrames.
+var ASCIIFadeFrames = Frames{
+	0: "#",
+	1: "#",
+	2: "%",
+	3: "%",
+	4: "+",
+	5: "+",
+	6: ".",
+	7: ".",
+	8: " ",
+	9: " ",
+}
+
+// DarkTheme is for terminals with a dark background. It only uses the 16
+// ANSI colors, so it follows the terminal's own palette.
+var DarkTheme = Theme{
+	Profile: termenv.ANSI,
+
+	Running:   "3",
+	Completed: "2",
+	Cached:    "4",
+	Failed:    "1",
+	Canceled:  "11",
+
+	Muted:  "8",
+	Accent: "6",
+
+	Debug:   "4",
+	Warning: "3",
+	Error:   "1",
+
+	Groups: []string{"2", "3", "4", "5", "6", "7", "1"},
+
+	Console: ConsoleColors{
+		VertexID: "5",
+		Group:    "4",
+		Cached:   "6",
+		Canceled: "3",
+	},
+
+	Glyphs: UnicodeGlyphs,
+}
+
+// LightTheme is for terminals with a light background. It uses darker
+// 256-color shades that stay readable on white, in particular for muted
+// text.
+var LightTheme = Theme{
+	Profile: termenv.ANSI256,
+
+	Running:   "130",
+	Completed: "28",
+	Cached:    "25",
+	Failed:    "124",
+	Canceled:  "166",
+
+	Muted:  "241",
+	Accent: "30",
+
+	Debug:   "25",
+	Warning: "130",
+	Error:   "124",
+
+	Groups: []string{"28", "130", "25", "90", "30", "238", "124"},
+
+	Glyphs: UnicodeGlyphs,
+}
+
+// DetectTheme returns the preset named by $PROGROCK_THEME, either "dark" or
+// "light", defaulting to DarkTheme, with its Profile set by EnvProfile. It
+// uses ASCIIGlyphs if the locale doesn't support Unicode.
+func DetectTheme() Theme {
+	theme := DarkTheme
+	if strings.EqualFold(os.Getenv("PROGROCK_THEME"), "light") {
+		theme = LightTheme
+	}
+
+	theme.Profile = EnvProfile()
+
+	if !UnicodeLocale() {
+		theme.Glyphs = ASCIIGlyphs
+	}
+
+	return theme
+}
+
+// UnicodeLocale returns false if the locale, as configured by $LC_ALL,
+// $LC_CTYPE, or $LANG, is set to anything other than a UTF-8 locale, such as
+// C or POSIX. It returns true if no locale is configured.
+func UnicodeLocale() bool {
+	for _, env := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		locale := os.Getenv(env)
+		if locale == "" {
+			continue
+		}
+
+		locale = strings.ToLower(locale)
+		return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+	}
+
+	return true
+}
+
+// EnvProfile detects the color profile from the environment. Color is
+// disabled if $NO_COLOR is set, truecolor is used if $COLORTERM says so, and
+// 256 colors are used if $TERM says so. Otherwise the 16 ANSI colors are
+// used.
+func EnvProfile() termenv.Profile {
+	if os.Getenv("NO_COLOR") != "" {
+		return termenv.Ascii
+	}
+
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return termenv.TrueColor
+	}
+
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return termenv.ANSI256
+	}
+
+	return termenv.ANSI
+}
+
+// Color renders the string in the given color.
+func (theme Theme) Color(color, str string) string {
+	return theme.Profile.String(str).Foreground(theme.Profile.Color(color)).String()
+}
+
+// GroupColor renders the string in the color for the i'th group.
+func (theme Theme) GroupColor(i int, str string) string {
+	if len(theme.Groups) == 0 {
+		return str
+	}
+
+	return theme.Color(theme.Groups[i%len(theme.Groups)], str)
+}
+
+// TemplateFuncs returns the termenv template functions for the theme's
+// profile, along with a Theme function for accessing the theme's colors and
+// glyphs, e.g. {{Foreground Theme.Muted Theme.Glyphs.Separator}}.
+func (theme Theme) TemplateFuncs() template.FuncMap {
+	funcs := template.FuncMap{}
+	for name, fn := range termenv.TemplateFuncs(theme.Profile) {
+		funcs[name] = fn
+	}
+
+	funcs["Theme"] = func() Theme {
+		return theme
+	}
+
+	return funcs
+}
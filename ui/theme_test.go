@@ -0,0 +1,42 @@
+package ui_test
+
+import (
+	"testing"
+
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/require"
+	"github.com/vito/progrock/ui"
+)
+
+func TestUnicodeLocale(t *testing.T) {
+	for _, example := range []struct {
+		lcAll, lcCtype, lang string
+		unicode              bool
+	}{
+		{unicode: true},
+		{lang: "en_US.UTF-8", unicode: true},
+		{lang: "en_US.utf8", unicode: true},
+		{lang: "C", unicode: false},
+		{lang: "POSIX", unicode: false},
+		{lcCtype: "C", lang: "en_US.UTF-8", unicode: false},
+		{lcAll: "en_US.UTF-8", lcCtype: "C", unicode: true},
+	} {
+		t.Setenv("LC_ALL", example.lcAll)
+		t.Setenv("LC_CTYPE", example.lcCtype)
+		t.Setenv("LANG", example.lang)
+		require.Equal(t, example.unicode, ui.UnicodeLocale(), "%+v", example)
+	}
+}
+
+func TestDetectTheme(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_CTYPE", "")
+	t.Setenv("LANG", "C")
+	t.Setenv("NO_COLOR", "1")
+	t.Setenv("PROGROCK_THEME", "light")
+
+	theme := ui.DetectTheme()
+	require.Equal(t, termenv.Ascii, theme.Profile)
+	require.Equal(t, ui.LightTheme.Muted, theme.Muted)
+	require.Equal(t, ui.ASCIIGlyphs, theme.Glyphs)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
 	"unicode"
 
@@ -22,6 +23,15 @@ type Vterm struct {
 
 	Prefix string
 
+	// Key bindings used for scrolling.
+	Keys KeyMap
+
+	// maximum number of lines to retain, or 0 for unlimited
+	maxLines int
+
+	// text to highlight, if any
+	highlight *regexp.Regexp
+
 	vt *vt100.VT100
 
 	viewBuf *bytes.Buffer
@@ -42,13 +52,14 @@ func NewVterm() *Vterm {
 		vt.DebugLogs = os.Stderr
 	}
 	return &Vterm{
+		Keys:    Keys,
 		vt:      vt,
 		viewBuf: new(bytes.Buffer),
 	}
 }
 
 func (term *Vterm) Write(p []byte) (int, error) {
-	atBottom := term.Offset+term.Height >= term.vt.UsedHeight()
+	atBottom := term.Offset+term.Height >= term.UsedHeight()
 	if term.Height == 0 {
 		atBottom = true
 	}
@@ -58,27 +69,69 @@ func (term *Vterm) Write(p []byte) (int, error) {
 		return n, err
 	}
 
+	term.truncate()
+
 	if atBottom {
-		term.Offset = max(0, term.vt.UsedHeight()-term.Height)
+		term.Offset = max(0, term.UsedHeight()-term.Height)
 	}
 
 	return n, nil
 }
 
 func (term *Vterm) UsedHeight() int {
-	return term.vt.UsedHeight()
+	used := term.vt.UsedHeight()
+	if term.maxLines > 0 && used > term.vt.Height {
+		// the vt100 doesn't forget its max height when scrolling
+		used = term.vt.Height
+	}
+	return used
 }
 
 func (term *Vterm) SetHeight(height int) {
-	atBottom := term.Offset+term.Height >= term.vt.UsedHeight()
+	atBottom := term.Offset+term.Height >= term.UsedHeight()
 
 	term.Height = height
 
 	if atBottom {
-		term.Offset = max(0, term.vt.UsedHeight()-term.Height)
+		term.Offset = max(0, term.UsedHeight()-term.Height)
 	}
 }
 
+// SetMaxLines limits the number of lines retained by the terminal. Once the
+// limit is reached the oldest lines are discarded as new lines are written. A
+// limit of 0 retains everything.
+func (term *Vterm) SetMaxLines(lines int) {
+	term.maxLines = lines
+	term.truncate()
+	term.Offset = max(0, min(term.Offset, term.UsedHeight()-term.Height))
+}
+
+// truncate discards the oldest lines beyond the configured limit and stops
+// the terminal from growing any further, so that it scrolls instead.
+func (term *Vterm) truncate() {
+	if term.maxLines <= 0 {
+		return
+	}
+
+	vt := term.vt
+	if vt.Height < term.maxLines {
+		return
+	}
+
+	vt.AutoResizeY = false
+
+	excess := vt.Height - term.maxLines
+	if excess == 0 {
+		return
+	}
+
+	// copy so the discarded rows can be garbage collected
+	vt.Content = append([][]rune(nil), vt.Content[excess:]...)
+	vt.Format = append([][]vt100.Format(nil), vt.Format[excess:]...)
+	vt.Height = term.maxLines
+	vt.Cursor.Y = max(0, vt.Cursor.Y-excess)
+}
+
 func (term *Vterm) SetWidth(width int) {
 	term.Width = width
 	term.vt.AutoResizeX = false // stop auto-resizing vterm width
@@ -104,25 +157,25 @@ func (term *Vterm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) { // nolint:gocritic
 	case tea.KeyMsg:
 		switch {
-		case key.Matches(msg, Keys.Up):
+		case key.Matches(msg, term.Keys.Up):
 			term.Offset = max(0, term.Offset-1)
-		case key.Matches(msg, Keys.Down):
-			term.Offset = min(term.vt.UsedHeight()-term.Height, term.Offset+1)
-		case key.Matches(msg, Keys.PageUp):
+		case key.Matches(msg, term.Keys.Down):
+			term.Offset = min(term.UsedHeight()-term.Height, term.Offset+1)
+		case key.Matches(msg, term.Keys.PageUp):
 			term.Offset = max(0, term.Offset-term.Height)
-		case key.Matches(msg, Keys.PageDown):
-			term.Offset = min(term.vt.UsedHeight()-term.Height, term.Offset+term.Height)
-		case key.Matches(msg, Keys.Home):
+		case key.Matches(msg, term.Keys.PageDown):
+			term.Offset = min(term.UsedHeight()-term.Height, term.Offset+term.Height)
+		case key.Matches(msg, term.Keys.Home):
 			term.Offset = 0
-		case key.Matches(msg, Keys.End):
-			term.Offset = term.vt.UsedHeight() - term.Height
+		case key.Matches(msg, term.Keys.End):
+			term.Offset = term.UsedHeight() - term.Height
 		}
 	}
 	return term, nil
 }
 
 func (term *Vterm) ScrollPercent() float64 {
-	return min(1, float64(term.Offset+term.Height)/float64(term.vt.UsedHeight()))
+	return min(1, float64(term.Offset+term.Height)/float64(term.UsedHeight()))
 }
 
 const reset = termenv.CSI + termenv.ResetSeq + "m"
@@ -136,7 +189,7 @@ func (term *Vterm) View() string {
 // Bytes returns the output for the given region of the terminal, with
 // ANSI formatting.
 func (term *Vterm) Bytes(offset, height int) []byte {
-	used := term.vt.UsedHeight()
+	used := term.UsedHeight()
 	if used == 0 {
 		return nil
 	}
@@ -157,12 +210,17 @@ func (term *Vterm) Bytes(offset, height int) []byte {
 
 		var lastFormat vt100.Format
 
+		highlighted := term.highlighted(line)
+
 		for col, r := range line {
 			f := term.vt.Format[row][col]
+			if highlighted != nil && highlighted[col] {
+				f.Reverse = !f.Reverse
+			}
 
 			if f != lastFormat {
 				lastFormat = f
-				buf.Write([]byte(renderFormat(f)))
+				buf.Write([]byte(RenderFormat(f)))
 			}
 
 			buf.Write([]byte(string(r)))
@@ -179,10 +237,110 @@ func (term *Vterm) Bytes(offset, height int) []byte {
 	return buf.Bytes()
 }
 
+// SetHighlight highlights all text matching the pattern. A nil pattern
+// clears the highlight.
+func (term *Vterm) SetHighlight(pattern *regexp.Regexp) {
+	term.highlight = pattern
+}
+
+// Search returns the rows containing text that matches the pattern.
+func (term *Vterm) Search(pattern *regexp.Regexp) []int {
+	var rows []int
+	used := term.UsedHeight()
+	for row := 0; row < used && row < len(term.vt.Content); row++ {
+		if pattern.MatchString(string(term.vt.Content[row])) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// ScrollTo scrolls the terminal so that the given row is at the top, as far
+// as possible.
+func (term *Vterm) ScrollTo(row int) {
+	term.Offset = max(0, min(row, term.UsedHeight()-term.Height))
+}
+
+// AtBottom returns true if the last line of output is visible.
+func (term *Vterm) AtBottom() bool {
+	return term.Offset+term.Height >= term.UsedHeight()
+}
+
+// highlighted returns which columns of the line match the highlight pattern,
+// or nil if there are none.
+func (term *Vterm) highlighted(line []rune) []bool {
+	if term.highlight == nil {
+		return nil
+	}
+
+	text := string(line)
+
+	matches := term.highlight.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	// map byte offsets to columns
+	cols := make([]int, len(text)+1)
+	col := 0
+	for i := range text {
+		cols[i] = col
+		col++
+	}
+	cols[len(text)] = col
+
+	highlighted := make([]bool, len(line))
+	for _, m := range matches {
+		for c := cols[m[0]]; c < cols[m[1]]; c++ {
+			highlighted[c] = true
+		}
+	}
+
+	return highlighted
+}
+
+// Snapshot returns the full output of the terminal with ANSI formatting and
+// without the prefix, such that writing it to a new Vterm reproduces it.
+func (term *Vterm) Snapshot() []byte {
+	buf := new(bytes.Buffer)
+
+	used := term.UsedHeight()
+	for row := 0; row < used && row < len(term.vt.Content); row++ {
+		line := term.vt.Content[row]
+
+		// trim trailing blanks so the line doesn't wrap if the new terminal is
+		// any narrower
+		end := len(line)
+		for end > 0 && line[end-1] == ' ' && term.vt.Format[row][end-1] == (vt100.Format{}) {
+			end--
+		}
+
+		var lastFormat vt100.Format
+		for col, r := range line[:end] {
+			f := term.vt.Format[row][col]
+
+			if f != lastFormat {
+				lastFormat = f
+				buf.WriteString(RenderFormat(f))
+			}
+
+			buf.WriteRune(r)
+		}
+
+		if lastFormat != (vt100.Format{}) {
+			buf.WriteString(reset)
+		}
+
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes()
+}
+
 // LastLine returns the last line of visible text, with ANSI formatting, but
 // without any trailing whitespace.
 func (term *Vterm) LastLine() string {
-	used := term.vt.UsedHeight()
+	used := term.UsedHeight()
 	if used == 0 {
 		return ""
 	}
@@ -197,7 +355,7 @@ func (term *Vterm) LastLine() string {
 
 			if f != lastFormat {
 				lastFormat = f
-				buf.Write([]byte(renderFormat(f)))
+				buf.Write([]byte(RenderFormat(f)))
 			}
 
 			buf.Write([]byte(string(r)))
@@ -217,7 +375,7 @@ func (term *Vterm) LastLine() string {
 
 // Print prints the full log output without any formatting.
 func (term *Vterm) Print(w io.Writer) error {
-	used := term.vt.UsedHeight()
+	used := term.UsedHeight()
 
 	for row, l := range term.vt.Content {
 		_, err := fmt.Fprintln(w, strings.TrimRight(string(l), " "))
@@ -233,7 +391,8 @@ func (term *Vterm) Print(w io.Writer) error {
 	return nil
 }
 
-func renderFormat(f vt100.Format) string {
+// RenderFormat returns the ANSI escape sequence for switching to the format.
+func RenderFormat(f vt100.Format) string {
 	styles := []string{}
 	if f.Fg != nil {
 		styles = append(styles, f.Fg.Sequence(false))
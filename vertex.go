@@ -6,15 +6,21 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 
 	"github.com/opencontainers/go-digest"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // VertexRecorder records updates pertaining to a vertex.
+//
+// It is safe for concurrent use.
 type VertexRecorder struct {
 	Vertex   *Vertex
 	Recorder *Recorder
+
+	// guards Vertex
+	vertexL sync.Mutex
 }
 
 // VertexOpt is an option for creating a Vertex.
@@ -45,6 +51,14 @@ func Focused() VertexOpt {
 	}
 }
 
+// WithMaxAttempts sets the maximum number of attempts that will be made to
+// evaluate the vertex.
+func WithMaxAttempts(max int) VertexOpt {
+	return func(vertex *Vertex) {
+		vertex.MaxAttempts = int32(max)
+	}
+}
+
 // Vertex creates a new VertexRecorder for the given vertex.
 //
 // While the digest can technically be an arbitrary string, it is given a
@@ -54,7 +68,7 @@ func Focused() VertexOpt {
 // Recorder keeps track of all group memberships seen for a given digest, and
 // will emit a union of all groups.
 func (recorder *Recorder) Vertex(dig digest.Digest, name string, opts ...VertexOpt) *VertexRecorder {
-	now := Clock.Now()
+	now := recorder.now()
 
 	vtx := &Vertex{
 		Id:      dig.String(),
@@ -104,7 +118,10 @@ func (recorder *VertexRecorder) Stderr() io.Writer {
 
 // Complete marks the vertex as completed and sends an update.
 func (recorder *VertexRecorder) Complete() {
-	now := Clock.Now()
+	recorder.vertexL.Lock()
+	defer recorder.vertexL.Unlock()
+
+	now := recorder.Recorder.now()
 
 	if recorder.Vertex.Completed == nil {
 		// avoid marking tasks as completed twice; could have been idempotently
@@ -120,6 +137,9 @@ func (recorder *VertexRecorder) Complete() {
 // If the error is context.Canceled or has it as a string suffix, the vertex
 // will be marked as canceled instead.
 func (recorder *VertexRecorder) Error(err error) {
+	recorder.vertexL.Lock()
+	defer recorder.vertexL.Unlock()
+
 	msg := err.Error()
 	if errors.Is(err, context.Canceled) || strings.HasSuffix(err.Error(), context.Canceled.Error()) {
 		recorder.Vertex.Canceled = true
@@ -131,6 +151,9 @@ func (recorder *VertexRecorder) Error(err error) {
 
 // Output records an output digest for the vertex and sends an update.
 func (recorder *VertexRecorder) Output(out digest.Digest) {
+	recorder.vertexL.Lock()
+	defer recorder.vertexL.Unlock()
+
 	recorder.Vertex.Outputs = append(recorder.Vertex.Outputs, out.String())
 	recorder.sync()
 }
@@ -143,13 +166,50 @@ func (recorder *VertexRecorder) Done(err error) {
 	recorder.Complete()
 }
 
+// NewAttempt marks the end of the current attempt and starts a new one,
+// sending an update.
+//
+// The current attempt's timing, error, and cancellation state are preserved in
+// the vertex's Attempts, and subsequent logs are recorded against the new
+// attempt.
+func (recorder *VertexRecorder) NewAttempt() {
+	recorder.vertexL.Lock()
+	defer recorder.vertexL.Unlock()
+
+	now := recorder.Recorder.now()
+
+	vtx := recorder.Vertex
+
+	attempt := &VertexAttempt{
+		Started:   vtx.Started,
+		Completed: vtx.Completed,
+		Error:     vtx.Error,
+		Canceled:  vtx.Canceled,
+	}
+
+	if attempt.Completed == nil {
+		attempt.Completed = timestamppb.New(now)
+	}
+
+	vtx.Attempts = append(vtx.Attempts, attempt)
+	vtx.Started = timestamppb.New(now)
+	vtx.Completed = nil
+	vtx.Error = nil
+	vtx.Canceled = false
+
+	recorder.sync()
+}
+
 // Cached marks the vertex as cached and sends an update.
 func (recorder *VertexRecorder) Cached() {
+	recorder.vertexL.Lock()
+	defer recorder.vertexL.Unlock()
+
 	recorder.Vertex.Cached = true
 	recorder.sync()
 }
 
-// sync sends an update for the vertex.
+// sync sends an update for the vertex. It must be called with vertexL held.
 func (recorder *VertexRecorder) sync() {
 	recorder.Recorder.Record(&StatusUpdate{
 		Vertexes: []*Vertex{
@@ -162,7 +222,7 @@ func (recorder *VertexRecorder) sync() {
 func (recorder *VertexRecorder) Task(msg string, args ...interface{}) *TaskRecorder {
 	name := fmt.Sprintf(msg, args...)
 
-	now := Clock.Now()
+	now := recorder.Recorder.now()
 
 	task := &VertexTask{
 		Vertex:  recorder.Vertex.Id,
@@ -187,7 +247,7 @@ func (recorder *VertexRecorder) Task(msg string, args ...interface{}) *TaskRecor
 func (recorder *VertexRecorder) ProgressTask(total int64, msg string, args ...interface{}) *TaskRecorder {
 	name := fmt.Sprintf(msg, args...)
 
-	now := Clock.Now()
+	now := recorder.Recorder.now()
 
 	task := &VertexTask{
 		Vertex:  recorder.Vertex.Id,
@@ -217,7 +277,11 @@ func (w *recordWriter) Write(b []byte) (int, error) {
 	d := make([]byte, len(b))
 	copy(d, b)
 
-	now := Clock.Now()
+	now := w.Recorder.now()
+
+	w.vertexL.Lock()
+	attempt := len(w.Vertex.Attempts)
+	w.vertexL.Unlock()
 
 	w.Recorder.Record(&StatusUpdate{
 		Logs: []*VertexLog{
@@ -226,6 +290,7 @@ func (w *recordWriter) Write(b []byte) (int, error) {
 				Stream:    w.Stream,
 				Data:      d,
 				Timestamp: timestamppb.New(now),
+				Attempt:   int32(attempt),
 			},
 		},
 	})